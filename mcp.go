@@ -2,15 +2,33 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode/utf8"
 )
 
 // Estruturas MCP
@@ -41,10 +59,14 @@ type InitializeResult struct {
 	ServerInfo      map[string]interface{} `json:"serverInfo"`
 }
 
+// Tool descreve uma ferramenta em tools/list. OutputSchema só é declarado
+// pelas ferramentas que devolvem structuredContent e deve descrever esse
+// objeto.
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
 }
 
 type CallToolParams struct {
@@ -53,18 +75,48 @@ type CallToolParams struct {
 }
 
 type CallToolResult struct {
-	Content []map[string]interface{} `json:"content"`
+	Content           []map[string]interface{} `json:"content"`
+	StructuredContent interface{}              `json:"structuredContent,omitempty"`
+
+	// jsonText indica que o texto é o JSON de output: "json", que
+	// limitResponseSize não pode cortar no meio.
+	jsonText bool
+}
+
+// MarshalJSON garante que content seja serializado como array (nunca null);
+// alguns clientes falham ao interpretar "content": null.
+func (r CallToolResult) MarshalJSON() ([]byte, error) {
+	type plainResult CallToolResult
+	if r.Content == nil {
+		r.Content = []map[string]interface{}{}
+	}
+	return json.Marshal(plainResult(r))
 }
 
 // Estruturas GitHub API
 type GitHubRepo struct {
-	Name        string `json:"name"`
-	FullName    string `json:"full_name"`
-	Description string `json:"description"`
-	Private     bool   `json:"private"`
-	HTMLURL     string `json:"html_url"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	Name            string   `json:"name"`
+	FullName        string   `json:"full_name"`
+	Description     string   `json:"description"`
+	Private         bool     `json:"private"`
+	Visibility      string   `json:"visibility"`
+	HTMLURL         string   `json:"html_url"`
+	CreatedAt       string   `json:"created_at"`
+	UpdatedAt       string   `json:"updated_at"`
+	Language        string   `json:"language"`
+	DefaultBranch   string   `json:"default_branch"`
+	StargazersCount int      `json:"stargazers_count"`
+	ForksCount      int      `json:"forks_count"`
+	Topics          []string `json:"topics"`
+	CloneURL        string   `json:"clone_url"`
+	SSHURL          string   `json:"ssh_url"`
+	GitURL          string   `json:"git_url"`
+
+	// Em forks, Parent é o repositório de onde o fork foi feito e Source é
+	// a raiz da rede de forks; o GitHub só os inclui em GET /repos/{owner}/{repo}.
+	Fork   bool        `json:"fork"`
+	Parent *GitHubRepo `json:"parent"`
+	Source *GitHubRepo `json:"source"`
 }
 
 type GitHubUser struct {
@@ -80,13 +132,20 @@ type GitHubUser struct {
 }
 
 type GitHubIssue struct {
-	Number    int    `json:"number"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
-	State     string `json:"state"`
-	HTMLURL   string `json:"html_url"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Number    int          `json:"number"`
+	Title     string       `json:"title"`
+	Body      string       `json:"body"`
+	State     string       `json:"state"`
+	HTMLURL   string       `json:"html_url"`
+	CreatedAt string       `json:"created_at"`
+	UpdatedAt string       `json:"updated_at"`
+	Assignees []GitHubUser `json:"assignees"`
+
+	// PullRequest só vem preenchido quando a "issue" é um pull request; o
+	// endpoint de issues devolve os dois.
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request"`
 }
 
 type GitHubPR struct {
@@ -99,15 +158,90 @@ type GitHubPR struct {
 	UpdatedAt string `json:"updated_at"`
 }
 
+// GitHubBranch é uma branch de /repos/{owner}/{repo}/branches, com o commit
+// da ponta.
+type GitHubBranch struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+	Protected bool `json:"protected"`
+}
+
 type GitHubCommit struct {
-	SHA     string `json:"sha"`
-	Message string `json:"message"`
-	Author  struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-		Date  string `json:"date"`
-	} `json:"author"`
-	HTMLURL string `json:"html_url"`
+	SHA     string             `json:"sha"`
+	Message string             `json:"message"`
+	Author  GitHubCommitAuthor `json:"author"`
+	HTMLURL string             `json:"html_url"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	Verification *GitHubVerification `json:"verification"`
+}
+
+// GitHubVerification é o resultado da verificação da assinatura (GPG, SSH
+// ou S/MIME) de um commit.
+type GitHubVerification struct {
+	Verified  bool   `json:"verified"`
+	Reason    string `json:"reason"`
+	Signature string `json:"signature"`
+}
+
+type GitHubCommitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+// UnmarshalJSON lê mensagem, autor e verificação do objeto "commit", onde a
+// API os coloca; o "author" de primeiro nível é a conta GitHub, sem
+// nome/email/data do git.
+func (c *GitHubCommit) UnmarshalJSON(data []byte) error {
+	type plainCommit GitHubCommit
+	var raw struct {
+		plainCommit
+		Commit *struct {
+			Message      string              `json:"message"`
+			Author       GitHubCommitAuthor  `json:"author"`
+			Verification *GitHubVerification `json:"verification"`
+		} `json:"commit"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = GitHubCommit(raw.plainCommit)
+	if raw.Commit != nil {
+		c.Message = raw.Commit.Message
+		c.Author = raw.Commit.Author
+		c.Verification = raw.Commit.Verification
+	}
+	return nil
+}
+
+// IsMerge indica se o commit é um merge (mais de um pai).
+func (c GitHubCommit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+type GitHubFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+	Patch     string `json:"patch,omitempty"`
+}
+
+// GitHubComparison é o resultado de comparar duas refs (base...head).
+type GitHubComparison struct {
+	Status       string         `json:"status"`
+	AheadBy      int            `json:"ahead_by"`
+	BehindBy     int            `json:"behind_by"`
+	TotalCommits int            `json:"total_commits"`
+	HTMLURL      string         `json:"html_url"`
+	Commits      []GitHubCommit `json:"commits"`
+	Files        []GitHubFile   `json:"files"`
 }
 
 type GitHubContent struct {
@@ -120,177 +254,2855 @@ type GitHubContent struct {
 	HTMLURL  string `json:"html_url"`
 }
 
+type GitHubRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Body        string `json:"body"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	PublishedAt string `json:"published_at"`
+	HTMLURL     string `json:"html_url"`
+}
+
+type GitHubInvitation struct {
+	ID          int64      `json:"id"`
+	Invitee     GitHubUser `json:"invitee"`
+	Inviter     GitHubUser `json:"inviter"`
+	Permissions string     `json:"permissions"`
+	CreatedAt   string     `json:"created_at"`
+	HTMLURL     string     `json:"html_url"`
+}
+
+// GitHubCodeResult é um arquivo encontrado pela busca de código. TextMatches só
+// é preenchido quando a busca pede o media type text-match.
+type GitHubCodeResult struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	HTMLURL    string `json:"html_url"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	TextMatches []struct {
+		Fragment string `json:"fragment"`
+	} `json:"text_matches"`
+}
+
+// GitHubIssueEvent é um evento estruturado de uma issue (labeled, assigned,
+// closed...). Actor pode ser nulo para usuários removidos.
+type GitHubIssueEvent struct {
+	Event     string      `json:"event"`
+	Actor     *GitHubUser `json:"actor"`
+	CreatedAt string      `json:"created_at"`
+	Label     *struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	Assignee *GitHubUser `json:"assignee"`
+}
+
+// GitHubArtifact é um artefato gerado por uma execução do GitHub Actions.
+type GitHubArtifact struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	SizeInBytes        int64  `json:"size_in_bytes"`
+	ArchiveDownloadURL string `json:"archive_download_url"`
+	Expired            bool   `json:"expired"`
+	ExpiresAt          string `json:"expires_at"`
+}
+
+// GitHubRuleset é um conjunto de regras aplicado a branches ou tags de um
+// repositório. A listagem não traz Conditions nem Rules; GetRuleset traz.
+type GitHubRuleset struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Target      string `json:"target"`
+	Enforcement string `json:"enforcement"`
+	SourceType  string `json:"source_type"`
+	Source      string `json:"source"`
+	Conditions  *struct {
+		RefName struct {
+			Include []string `json:"include"`
+			Exclude []string `json:"exclude"`
+		} `json:"ref_name"`
+	} `json:"conditions"`
+	Rules []struct {
+		Type string `json:"type"`
+	} `json:"rules"`
+}
+
+// GitHubStargazer é uma estrela dada ao repositório, com a data em que foi
+// dada (media type star+json).
+type GitHubStargazer struct {
+	StarredAt string     `json:"starred_at"`
+	User      GitHubUser `json:"user"`
+}
+
+// GitHubEnvironment é um ambiente de deploy do repositório.
+type GitHubEnvironment struct {
+	Name            string `json:"name"`
+	HTMLURL         string `json:"html_url"`
+	ProtectionRules []struct {
+		Type      string        `json:"type"`
+		WaitTimer int           `json:"wait_timer"`
+		Reviewers []interface{} `json:"reviewers"`
+	} `json:"protection_rules"`
+	DeploymentBranchPolicy *struct {
+		ProtectedBranches    bool `json:"protected_branches"`
+		CustomBranchPolicies bool `json:"custom_branch_policies"`
+	} `json:"deployment_branch_policy"`
+}
+
+// GitHubActivity é uma alteração de ref registrada no feed de atividade do
+// repositório (push, force push, merge, criação ou remoção de branch).
+type GitHubActivity struct {
+	Ref          string      `json:"ref"`
+	Before       string      `json:"before"`
+	After        string      `json:"after"`
+	Timestamp    string      `json:"timestamp"`
+	ActivityType string      `json:"activity_type"`
+	Actor        *GitHubUser `json:"actor"`
+}
+
+// activityTypes são os filtros aceitos por GetRepoActivity.
+var activityTypes = []string{"push", "force_push", "branch_creation", "branch_deletion", "pr_merge", "merge_queue_merge"}
+
+// GitHubOrg traz os metadados de uma organização. TotalPrivateRepos só vem
+// preenchido para membros com acesso aos repositórios privados.
+type GitHubOrg struct {
+	Login             string `json:"login"`
+	Name              string `json:"name"`
+	PublicRepos       int    `json:"public_repos"`
+	TotalPrivateRepos *int   `json:"total_private_repos"`
+	HTMLURL           string `json:"html_url"`
+}
+
+// GitHubCheckSuite agrupa as check runs criadas por um app para um commit.
+type GitHubCheckSuite struct {
+	ID         int64  `json:"id"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadBranch string `json:"head_branch"`
+	App        *struct {
+		Name string `json:"name"`
+	} `json:"app"`
+}
+
+// GitHubCheckSuites é a resposta da listagem de check suites.
+type GitHubCheckSuites struct {
+	TotalCount  int                `json:"total_count"`
+	CheckSuites []GitHubCheckSuite `json:"check_suites"`
+}
+
+// GitHubPages descreve a configuração do GitHub Pages de um repositório.
+type GitHubPages struct {
+	Status        string `json:"status"`
+	HTMLURL       string `json:"html_url"`
+	CNAME         string `json:"cname"`
+	BuildType     string `json:"build_type"`
+	HTTPSEnforced bool   `json:"https_enforced"`
+	Source        *struct {
+		Branch string `json:"branch"`
+		Path   string `json:"path"`
+	} `json:"source"`
+}
+
+// GitHubReviewRequest traz os revisores pendentes de um pull request, como
+// devolvidos após um pedido de revisão.
+type GitHubReviewRequest struct {
+	Number             int          `json:"number"`
+	HTMLURL            string       `json:"html_url"`
+	RequestedReviewers []GitHubUser `json:"requested_reviewers"`
+	RequestedTeams     []struct {
+		Slug string `json:"slug"`
+	} `json:"requested_teams"`
+}
+
+// GitHubIssueComment é um comentário de issue ou pull request.
+type GitHubIssueComment struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	HTMLURL   string     `json:"html_url"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+	User      GitHubUser `json:"user"`
+}
+
+// GitHubTree é uma árvore git. Truncated indica que a API cortou a lista de
+// entradas (árvores muito grandes), e a árvore está incompleta.
+type GitHubTree struct {
+	SHA       string            `json:"sha"`
+	Tree      []GitHubTreeEntry `json:"tree"`
+	Truncated bool              `json:"truncated"`
+}
+
+// GitHubTreeEntry é um item da árvore; Size só vem preenchido para blobs.
+type GitHubTreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+	Size int64  `json:"size"`
+}
+
+// GitHubCommitDetail é um commit como devolvido por GET
+// /repos/{owner}/{repo}/commits/{sha}: com os totais de linhas e os arquivos.
+type GitHubCommitDetail struct {
+	SHA   string `json:"sha"`
+	Stats struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+		Total     int `json:"total"`
+	} `json:"stats"`
+	Files []GitHubFile `json:"files"`
+}
+
+// GitHubGitObject é o objeto apontado por uma ref ou por uma tag anotada.
+type GitHubGitObject struct {
+	Object struct {
+		SHA  string `json:"sha"`
+		Type string `json:"type"`
+	} `json:"object"`
+}
+
+// GitHubReaction é uma reação (emoji) deixada em uma issue ou pull request.
+type GitHubReaction struct {
+	ID      int64      `json:"id"`
+	Content string     `json:"content"`
+	User    GitHubUser `json:"user"`
+}
+
+// reactionContents são os valores de reação aceitos pela API.
+var reactionContents = []string{"+1", "-1", "laugh", "heart", "hooray", "confused", "rocket", "eyes"}
+
+// GitHubSubscription é a inscrição do usuário autenticado nas notificações de
+// um repositório.
+type GitHubSubscription struct {
+	Subscribed bool   `json:"subscribed"`
+	Ignored    bool   `json:"ignored"`
+	Reason     string `json:"reason"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// GitHubTokenInfo descreve o usuário autenticado e o token em uso.
+type GitHubTokenInfo struct {
+	User               GitHubUser
+	Scopes             []string
+	FineGrained        bool
+	RateLimitLimit     int
+	RateLimitRemaining int
+	RateLimitReset     time.Time
+}
+
+// GitHubCodeFrequency é uma semana do histórico de adições/remoções.
+type GitHubCodeFrequency struct {
+	Week      int64 `json:"week"`
+	Additions int   `json:"additions"`
+	Deletions int   `json:"deletions"`
+}
+
 // Cliente GitHub
 type GitHubClient struct {
 	token   string
 	baseURL string
 	client  *http.Client
+	perPage int
+
+	statsRetries    int
+	statsRetryDelay time.Duration
+
+	secondaryRetries  int
+	secondaryMaxDelay time.Duration
+
+	// networkRetries repete requisições que falharam por erro de rede
+	// transitório, antes de qualquer resposta; a espera começa em
+	// networkRetryDelay e dobra a cada tentativa.
+	networkRetries    int
+	networkRetryDelay time.Duration
+
+	// maxRetries repete GETs que receberam 502, 503 ou 504, com a mesma
+	// espera exponencial dos erros de rede ou o Retry-After da resposta.
+	maxRetries int
+
+	// allowedHosts e allowInsecure restringem para onde baseURL pode apontar,
+	// evitando que o cliente seja usado contra serviços internos.
+	allowedHosts  []string
+	allowInsecure bool
+
+	// trace registra no log cada requisição feita ao GitHub.
+	trace bool
+
+	// retries é compartilhado entre as cópias de WithToken e limita o total
+	// de novas tentativas por minuto.
+	retries *retryBudget
+
+	// branches guarda a branch padrão de cada repositório (DefaultBranch).
+	// WithToken cria um cache novo, já que outro token pode ver outros
+	// repositórios.
+	branches *branchCache
+
+	requestInterceptors  []RequestInterceptor
+	responseInterceptors []ResponseInterceptor
 }
 
-func NewGitHubClient(token string) *GitHubClient {
-	return &GitHubClient{
-		token:   token,
-		baseURL: "https://api.github.com",
-		client:  &http.Client{Timeout: 30 * time.Second},
-	}
+// RequestInterceptor é chamado com cada requisição pronta, antes do envio,
+// e pode alterá-la (cabeçalhos, autenticação...). Um erro cancela a
+// requisição.
+type RequestInterceptor func(*http.Request) error
+
+// ResponseInterceptor é chamado com cada resposta recebida, antes de ela ser
+// tratada. Um erro descarta a resposta e é devolvido a quem fez a chamada.
+type ResponseInterceptor func(*http.Response) error
+
+// AddRequestInterceptor registra um interceptor de requisições. Os
+// interceptors rodam na ordem em que foram registrados, a cada tentativa
+// (inclusive nas repetições por rate limit).
+func (gc *GitHubClient) AddRequestInterceptor(fn RequestInterceptor) {
+	gc.requestInterceptors = append(gc.requestInterceptors[:len(gc.requestInterceptors):len(gc.requestInterceptors)], fn)
 }
 
-func (gc *GitHubClient) makeRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, gc.baseURL+endpoint, body)
-	if err != nil {
-		return nil, err
+// AddResponseInterceptor registra um interceptor de respostas, executado na
+// ordem de registro.
+func (gc *GitHubClient) AddResponseInterceptor(fn ResponseInterceptor) {
+	gc.responseInterceptors = append(gc.responseInterceptors[:len(gc.responseInterceptors):len(gc.responseInterceptors)], fn)
+}
+
+const (
+	defaultAPIHost = "api.github.com"
+	defaultBaseURL = "https://" + defaultAPIHost
+)
+
+func normalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return defaultBaseURL
 	}
+	return baseURL
+}
 
-	req.Header.Set("Authorization", "token "+gc.token)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("User-Agent", "MCP-GitHub-Server/1.0")
+// Limites do parâmetro per_page aceitos pela API do GitHub.
+const (
+	defaultPerPage = 100
+	maxPerPage     = 100
+)
+
+// Espera padrão para endpoints de estatísticas que respondem 202.
+const (
+	defaultStatsRetries    = 3
+	defaultStatsRetryDelay = 2 * time.Second
+)
+
+// Backoff para o limite secundário de requisições. O GitHub recomenda esperar
+// pelo menos um minuto antes de tentar de novo.
+const (
+	defaultSecondaryRetries  = 2
+	secondaryBaseDelay       = time.Minute
+	defaultSecondaryMaxDelay = 5 * time.Minute
+
+	defaultNetworkRetries    = 2
+	defaultNetworkRetryDelay = time.Second
+
+	defaultMaxRetries   = 3
+	maxServerRetryDelay = time.Minute
+)
+
+// errStatsNotReady indica que o GitHub ainda está calculando as estatísticas
+// pedidas (resposta 202) mesmo após as novas tentativas.
+var errStatsNotReady = errors.New("estatísticas ainda não estão prontas, tente novamente em instantes")
 
-	return gc.client.Do(req)
+// RateLimitError indica que o limite primário de requisições do token se
+// esgotou (403/429 com X-RateLimit-Remaining: 0). Reset é quando o GitHub
+// renova o limite; zero se o cabeçalho X-RateLimit-Reset não veio.
+type RateLimitError struct {
+	Reset     time.Time
+	RequestID string
 }
 
-func (gc *GitHubClient) GetUser(ctx context.Context, username string) (*GitHubUser, error) {
-	endpoint := "/users/" + username
-	if username == "" {
-		endpoint = "/user"
+func (e *RateLimitError) Error() string {
+	msg := "limite de requisições do GitHub excedido"
+	if !e.Reset.IsZero() {
+		msg += ", renova às " + e.Reset.Format("15:04:05")
 	}
-
-	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id: %s)", e.RequestID)
 	}
-	defer resp.Body.Close()
+	return msg
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+// rateLimitError devolve um *RateLimitError se resp indica o limite primário
+// esgotado, ou nil. Respostas do limite secundário, que podem trazer os mesmos
+// cabeçalhos, são tratadas antes por isSecondaryRateLimit.
+func rateLimitError(resp *http.Response) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
 	}
-
-	var user GitHubUser
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, err
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
 	}
-
-	return &user, nil
+	err := &RateLimitError{RequestID: requestID(resp)}
+	if reset, parseErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+		err.Reset = time.Unix(reset, 0)
+	}
+	return err
 }
 
-func (gc *GitHubClient) GetRepos(ctx context.Context, username string) ([]GitHubRepo, error) {
-	endpoint := "/users/" + username + "/repos"
-	if username == "" {
-		endpoint = "/user/repos"
+// NewGitHubClient cria um cliente para a API em baseURL (ex.:
+// https://ghe.empresa.com/api/v3 no GitHub Enterprise Server); vazio usa
+// defaultBaseURL. Barras no fim são removidas para que os endpoints, que
+// começam com /, sejam concatenados direto. O host ainda precisa estar em
+// allowedHosts (veja checkBaseURL).
+func NewGitHubClient(token, baseURL string) *GitHubClient {
+	return &GitHubClient{
+		token:   token,
+		baseURL: normalizeBaseURL(baseURL),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		perPage: defaultPerPage,
+
+		statsRetries:    defaultStatsRetries,
+		statsRetryDelay: defaultStatsRetryDelay,
+
+		secondaryRetries:  defaultSecondaryRetries,
+		secondaryMaxDelay: defaultSecondaryMaxDelay,
+
+		networkRetries:    defaultNetworkRetries,
+		networkRetryDelay: defaultNetworkRetryDelay,
+
+		maxRetries: defaultMaxRetries,
+
+		allowedHosts: []string{defaultAPIHost},
+
+		retries: newRetryBudget(defaultRetryBudget),
+
+		branches: newBranchCache(),
 	}
+}
 
-	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+// checkBaseURL valida baseURL contra a lista de hosts permitidos. URLs sem
+// HTTPS só são aceitas com allowInsecure.
+func (gc *GitHubClient) checkBaseURL() error {
+	u, err := url.Parse(gc.baseURL)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("URL base inválida %q: %v", gc.baseURL, err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+	if u.Scheme != "https" && !(u.Scheme == "http" && gc.allowInsecure) {
+		return fmt.Errorf("URL base %q rejeitada: use https (ou defina ALLOW_INSECURE=true)", gc.baseURL)
 	}
 
-	var repos []GitHubRepo
-	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
-		return nil, err
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range gc.allowedHosts {
+		if host == allowed {
+			return nil
+		}
 	}
+	return fmt.Errorf("URL base %q rejeitada: host %q não está na lista de hosts permitidos", gc.baseURL, host)
+}
 
-	return repos, nil
+// WithToken devolve uma cópia do cliente, com a mesma configuração, que
+// autentica com outro token. O cliente original não é alterado.
+func (gc *GitHubClient) WithToken(token string) *GitHubClient {
+	clone := *gc
+	clone.token = token
+	clone.branches = newBranchCache()
+	return &clone
 }
 
-func (gc *GitHubClient) GetIssues(ctx context.Context, owner, repo string) ([]GitHubIssue, error) {
-	endpoint := fmt.Sprintf("/repos/%s/%s/issues", owner, repo)
+// WithRetries devolve uma cópia do cliente que repete até n vezes as falhas
+// transitórias: erros de rede e respostas 502/503/504 a GETs. Com n = 0
+// nenhuma delas é repetida. O cliente original não é alterado.
+func (gc *GitHubClient) WithRetries(n int) *GitHubClient {
+	clone := *gc
+	clone.maxRetries = n
+	clone.networkRetries = n
+	return &clone
+}
 
-	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+// defaultBranchTTL é por quanto tempo a branch padrão de um repositório fica
+// em cache.
+const defaultBranchTTL = 5 * time.Minute
+
+// branchCache guarda a branch padrão por repositório. As chaves vêm de
+// repoCacheKey, então Owner/Repo e owner/repo dividem a mesma entrada.
+type branchCache struct {
+	mu      sync.Mutex
+	entries map[string]branchCacheEntry
+}
+
+type branchCacheEntry struct {
+	branch  string
+	expires time.Time
+}
+
+func newBranchCache() *branchCache {
+	return &branchCache{entries: make(map[string]branchCacheEntry)}
+}
+
+func (c *branchCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return "", false
 	}
-	defer resp.Body.Close()
+	return entry.branch, true
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+func (c *branchCache) set(key, branch string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = branchCacheEntry{branch: branch, expires: time.Now().Add(defaultBranchTTL)}
+}
+
+// repoCacheKey é a chave de cache de owner/repo. O GitHub não diferencia
+// maiúsculas nesses nomes, então a chave é minúscula; o texto exibido
+// continua com a grafia recebida.
+func repoCacheKey(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+// DefaultBranch devolve a branch padrão do repositório, guardada por
+// defaultBranchTTL para que as ferramentas que só precisam dela não busquem o
+// repositório a cada chamada.
+func (gc *GitHubClient) DefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	key := repoCacheKey(owner, repo)
+	if branch, ok := gc.branches.get(key); ok {
+		return branch, nil
 	}
 
-	var issues []GitHubIssue
-	if err := json.NewDecoder(resp.Body).Decode(&issues); err != nil {
-		return nil, err
+	repository, err := gc.GetRepo(ctx, owner, repo)
+	if err != nil {
+		return "", err
 	}
+	gc.branches.set(key, repository.DefaultBranch)
+	return repository.DefaultBranch, nil
+}
 
-	return issues, nil
+// getAllPages percorre todas as páginas de um endpoint de listagem seguindo o
+// cabeçalho Link (rel="next"), chamando decode com o corpo de cada página.
+func (gc *GitHubClient) getAllPages(ctx context.Context, endpoint string, decode func(io.Reader) error) error {
+	_, err := gc.getPages(ctx, endpoint, mediaType("default"), 0, decode)
+	return err
 }
 
-func (gc *GitHubClient) GetPullRequests(ctx context.Context, owner, repo string) ([]GitHubPR, error) {
-	endpoint := fmt.Sprintf("/repos/%s/%s/pulls", owner, repo)
+// getPages é como getAllPages, mas pede o media type accept e para depois de
+// maxPages páginas (0 = sem limite). Informa se parou antes da última página.
+func (gc *GitHubClient) getPages(ctx context.Context, endpoint, accept string, maxPages int, decode func(io.Reader) error) (bool, error) {
+	for pages := 0; endpoint != ""; pages++ {
+		if maxPages > 0 && pages == maxPages {
+			return true, nil
+		}
 
-	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+		resp, err := gc.makeRequestWithAccept(ctx, "GET", endpoint, accept, nil)
+		if err != nil {
+			return false, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return false, apiError(resp)
+		}
+
+		err = decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return false, err
+		}
+
+		endpoint, err = gc.nextPageEndpoint(resp)
+		if err != nil {
+			return false, err
+		}
 	}
-	defer resp.Body.Close()
+	return false, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+// nextPageEndpoint devolve o endpoint (relativo a baseURL) da próxima página
+// indicada no cabeçalho Link, ou "" quando não há próxima página.
+func (gc *GitHubClient) nextPageEndpoint(resp *http.Response) (string, error) {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		isNext := false
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				isNext = true
+			}
+		}
+		if !isNext {
+			continue
+		}
+
+		next := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+		if !strings.HasPrefix(next, gc.baseURL) {
+			return "", fmt.Errorf("link de paginação inesperado: %s", next)
+		}
+		return strings.TrimPrefix(next, gc.baseURL), nil
 	}
+	return "", nil
+}
 
-	var prs []GitHubPR
-	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
-		return nil, err
+// mediaTypes concentra os media types (cabeçalho Accept) pedidos por recurso.
+// Alguns previews já graduaram e hoje funcionam também com o media type
+// padrão, mas continuam aqui para que cada ferramenta peça sempre o mesmo
+// valor; quando um preview graduar ou mudar, basta atualizar esta tabela.
+var mediaTypes = map[string]string{
+	"default":       "application/vnd.github.v3+json",
+	"diff":          "application/vnd.github.v3.diff",
+	"sha":           "application/vnd.github.sha",
+	"stars":         "application/vnd.github.star+json",
+	"text_match":    "application/vnd.github.text-match+json",
+	"topics":        "application/vnd.github.mercy-preview+json",
+	"reactions":     "application/vnd.github.squirrel-girl-preview+json",
+	"timeline":      "application/vnd.github.mockingbird-preview+json",
+	"commit_search": "application/vnd.github.cloak-preview+json",
+}
+
+// mediaType devolve o media type do recurso feature, ou o padrão da API v3
+// para recursos sem entrada em mediaTypes.
+func mediaType(feature string) string {
+	if accept, ok := mediaTypes[feature]; ok {
+		return accept
 	}
+	return mediaTypes["default"]
+}
 
-	return prs, nil
+// listQuery monta os parâmetros comuns dos endpoints de listagem.
+func (gc *GitHubClient) listQuery() url.Values {
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(gc.perPage))
+	return query
 }
 
-func (gc *GitHubClient) GetCommits(ctx context.Context, owner, repo string) ([]GitHubCommit, error) {
-	endpoint := fmt.Sprintf("/repos/%s/%s/commits", owner, repo)
+// PageOptions escolhe que página de uma listagem buscar. Campos zerados usam
+// os padrões: primeira página e o per_page configurado no cliente. Com All,
+// Page é ignorado e todas as páginas são percorridas pelo cabeçalho Link.
+type PageOptions struct {
+	Page    int
+	PerPage int
+	All     bool
+}
 
-	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+func (o PageOptions) apply(query url.Values) {
+	if o.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(o.PerPage))
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+	if o.Page > 0 && !o.All {
+		query.Set("page", strconv.Itoa(o.Page))
 	}
+}
 
-	var commits []GitHubCommit
-	if err := json.NewDecoder(resp.Body).Decode(&commits); err != nil {
-		return nil, err
+// getList busca a página de endpoint escolhida em opts ou, com opts.All,
+// todas as páginas, chamando decode com o corpo de cada uma.
+func (gc *GitHubClient) getList(ctx context.Context, endpoint, accept string, opts PageOptions, decode func(io.Reader) error) error {
+	maxPages := 1
+	if opts.All {
+		maxPages = 0
 	}
+	_, err := gc.getPages(ctx, endpoint, accept, maxPages, decode)
+	return err
+}
 
-	return commits, nil
+func (gc *GitHubClient) makeRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Response, error) {
+	return gc.makeRequestWithAccept(ctx, method, endpoint, mediaType("default"), body)
 }
 
-func (gc *GitHubClient) GetContent(ctx context.Context, owner, repo, path string) (*GitHubContent, error) {
-	endpoint := fmt.Sprintf("/repos/%s/%s/contents/%s", owner, repo, path)
+// makeRequestWithAccept é como makeRequest, mas permite pedir outro media type
+// (diff, patch, previews...).
+//
+// Quatro situações fazem a requisição ser repetida:
+//   - erros de rede transitórios antes de qualquer resposta (timeout,
+//     conexão recusada ou derrubada, falha temporária de DNS), até
+//     networkRetries tentativas;
+//   - respostas 502, 503 e 504 a GETs, até maxRetries tentativas, desde que
+//     a espera caiba no prazo do contexto; 4xx nunca é repetido;
+//   - endpoints de estatísticas (/stats/) respondem 202 enquanto o GitHub
+//     calcula os dados; após statsRetries tentativas devolve errStatsNotReady;
+//   - o limite secundário de requisições (403/429 com a mensagem "secondary
+//     rate limit") é tratado com uma espera maior e limitada, até
+//     secondaryRetries tentativas.
+//
+// Cada nova tentativa também consome o retryBudget compartilhado; sem saldo a
+// requisição falha na hora com errRetryBudgetExhausted.
+func (gc *GitHubClient) makeRequestWithAccept(ctx context.Context, method, endpoint, accept string, body io.Reader) (*http.Response, error) {
+	// O corpo é lido uma vez para poder ser reenviado em novas tentativas.
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
 
-	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+	isStats := strings.Contains(endpoint, "/stats/")
+	statsAttempts, secondaryAttempts, networkAttempts, serverAttempts := 0, 0, 0, 0
+
+	for {
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+
+		resp, err := gc.doRequest(ctx, method, endpoint, accept, reader)
+		if err != nil {
+			if networkAttempts >= gc.networkRetries || !retryableNetworkError(ctx, method, err) {
+				return nil, err
+			}
+			if !gc.retries.take() {
+				return nil, fmt.Errorf("%w (%w)", err, errRetryBudgetExhausted)
+			}
+			wait := gc.networkRetryDelay << uint(networkAttempts)
+			networkAttempts++
+			log.Printf("Erro de rede em %s %s (%v); nova tentativa em %s", method, endpoint, err, wait)
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var wait time.Duration
+		switch {
+		case isStats && resp.StatusCode == http.StatusAccepted:
+			resp.Body.Close()
+			if statsAttempts >= gc.statsRetries {
+				return nil, errStatsNotReady
+			}
+			if !gc.retries.take() {
+				return nil, fmt.Errorf("%w (%w)", errStatsNotReady, errRetryBudgetExhausted)
+			}
+			statsAttempts++
+			wait = gc.statsRetryDelay
+
+		case isSecondaryRateLimit(resp):
+			resp.Body.Close()
+			if secondaryAttempts >= gc.secondaryRetries {
+				return nil, fmt.Errorf("limite secundário de requisições do GitHub excedido após %d tentativas; aguarde alguns minutos antes de tentar novamente%s", secondaryAttempts+1, requestIDSuffix(resp))
+			}
+			if !gc.retries.take() {
+				return nil, fmt.Errorf("limite secundário de requisições do GitHub excedido%s (%w)", requestIDSuffix(resp), errRetryBudgetExhausted)
+			}
+			wait = secondaryRateLimitDelay(resp, secondaryAttempts, gc.secondaryMaxDelay)
+			secondaryAttempts++
+			log.Printf("Limite secundário do GitHub atingido em %s %s; nova tentativa em %s", method, endpoint, wait)
+
+		case method == "GET" && serverAttempts < gc.maxRetries && isTransientServerError(resp):
+			wait = serverRetryDelay(resp, serverAttempts, gc.networkRetryDelay)
+			// Sem tempo ou saldo para esperar, a própria resposta de erro é
+			// devolvida a quem chamou.
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return resp, nil
+			}
+			if !gc.retries.take() {
+				return resp, nil
+			}
+			resp.Body.Close()
+			serverAttempts++
+			log.Printf("GitHub respondeu %s em %s %s; nova tentativa em %s", resp.Status, method, endpoint, wait)
+
+		default:
+			// O limite primário só renova em X-RateLimit-Reset, então não
+			// há nova tentativa.
+			if rateErr := rateLimitError(resp); rateErr != nil {
+				resp.Body.Close()
+				return nil, rateErr
+			}
+			return resp, nil
+		}
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+// retryableNetworkError diz se err, devolvido sem nenhuma resposta HTTP, é
+// uma falha de rede transitória que vale repetir. Cancelamento do contexto e
+// erros permanentes (host inexistente, certificado inválido...) não são
+// repetidos. Conexão recusada e falha temporária de DNS significam que nada
+// foi enviado e valem para qualquer método; timeout e conexão derrubada podem
+// ter ocorrido depois de o GitHub receber o pedido, por isso só são repetidos
+// em métodos idempotentes.
+func retryableNetworkError(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
 	}
 
-	var content GitHubContent
-	if err := json.NewDecoder(resp.Body).Decode(&content); err != nil {
-		return nil, err
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
 	}
 
-	return &content, nil
+	if method == "POST" || method == "PATCH" {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
 }
 
-// Servidor MCP
-type MCPServer struct {
-	github *GitHubClient
-	tools  []Tool
+// defaultRetryBudget é quantas novas tentativas por minuto todas as
+// requisições da sessão podem fazer juntas.
+const defaultRetryBudget = 30
+
+var errRetryBudgetExhausted = errors.New("orçamento de novas tentativas esgotado; sem repetir a requisição")
+
+// retryBudget é um token bucket que limita as novas tentativas de todas as
+// requisições: durante uma instabilidade do GitHub, chamadas falhando em
+// paralelo não multiplicam a carga. Um budget nil não impõe limite.
+type retryBudget struct {
+	mu        sync.Mutex
+	perMinute float64
+	tokens    float64
+	last      time.Time
 }
 
-func NewMCPServer(token string) *MCPServer {
-	return &MCPServer{
-		github: NewGitHubClient(token),
-		tools: []Tool{
-			{
+func newRetryBudget(perMinute int) *retryBudget {
+	return &retryBudget{perMinute: float64(perMinute), tokens: float64(perMinute), last: time.Now()}
+}
+
+// take consome uma nova tentativa, se houver saldo. O saldo é reposto
+// continuamente à taxa de perMinute por minuto, até perMinute.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Minutes() * b.perMinute
+	if b.tokens > b.perMinute {
+		b.tokens = b.perMinute
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// isSecondaryRateLimit identifica a resposta do limite secundário do GitHub,
+// que chega como 403/429 com uma mensagem própria no corpo. O corpo lido é
+// devolvido à resposta para que quem chamou ainda possa consumi-lo.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	prefix, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+
+	return bytes.Contains(bytes.ToLower(prefix), []byte("secondary rate limit"))
+}
+
+// isTransientServerError identifica as respostas de indisponibilidade
+// passageira do GitHub (502, 503 e 504).
+func isTransientServerError(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// serverRetryDelay calcula a espera antes de repetir um 5xx: o Retry-After da
+// resposta, se houver, ou baseDelay dobrando a cada tentativa. O resultado
+// nunca passa de maxServerRetryDelay.
+func serverRetryDelay(resp *http.Response, attempt int, baseDelay time.Duration) time.Duration {
+	delay := baseDelay << uint(attempt)
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay > maxServerRetryDelay {
+		delay = maxServerRetryDelay
+	}
+	return delay
+}
+
+// secondaryRateLimitDelay calcula a espera antes da próxima tentativa: usa
+// Retry-After ou X-RateLimit-Reset quando presentes e, sem eles, um backoff
+// exponencial a partir de um minuto. O resultado nunca passa de maxDelay.
+func secondaryRateLimitDelay(resp *http.Response, attempt int, maxDelay time.Duration) time.Duration {
+	delay := secondaryBaseDelay << uint(attempt)
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			delay = time.Until(time.Unix(reset, 0))
+		}
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (gc *GitHubClient) doRequest(ctx context.Context, method, endpoint, accept string, body io.Reader) (*http.Response, error) {
+	if err := gc.checkBaseURL(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, gc.baseURL+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sem token (servidor iniciado sem credenciais) as requisições são
+	// anônimas e só alcançam dados públicos.
+	if gc.token != "" {
+		req.Header.Set("Authorization", "token "+gc.token)
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "MCP-GitHub-Server/1.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, intercept := range gc.requestInterceptors {
+		if err := intercept(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := gc.client.Do(req)
+	if gc.trace {
+		if err != nil {
+			log.Printf("GitHub %s %s: %v", method, endpoint, err)
+		} else {
+			log.Printf("GitHub %s %s -> %s (request id: %s)", method, endpoint, resp.Status, requestID(resp))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, intercept := range gc.responseInterceptors {
+		if err := intercept(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// requestID devolve o X-GitHub-Request-Id da resposta, identificador que o
+// suporte do GitHub pede ao investigar problemas.
+func requestID(resp *http.Response) string {
+	return resp.Header.Get("X-GitHub-Request-Id")
+}
+
+// requestIDSuffix formata o request id para ser anexado a mensagens de erro,
+// ou "" quando a resposta não o trouxe.
+func requestIDSuffix(resp *http.Response) string {
+	if id := requestID(resp); id != "" {
+		return fmt.Sprintf(" (request id: %s)", id)
+	}
+	return ""
+}
+
+// maxJSONBodySize limita quanto de uma resposta JSON é lido para a memória.
+const maxJSONBodySize = 16 << 20
+
+// decodeJSON lê o corpo inteiro (até maxJSONBodySize) e o decodifica em v.
+// Se o corpo não for JSON válido — uma página HTML de proxy, uma resposta
+// cortada —, o erro traz o começo do corpo para deixar claro o que chegou.
+func decodeJSON(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(r, maxJSONBodySize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxJSONBodySize {
+		return fmt.Errorf("resposta JSON maior que %d bytes", maxJSONBodySize)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		snippet, truncated := truncateText(string(data), 200)
+		if truncated {
+			snippet += "..."
+		}
+		return fmt.Errorf("resposta não é JSON válido (%v); início do corpo: %q", err, snippet)
+	}
+	return nil
+}
+
+// GitHubAPIError é uma resposta da API com status inesperado. Message é o
+// campo message do corpo de erro do GitHub ({"message": "Not Found", ...}),
+// vazio quando o corpo não o traz.
+type GitHubAPIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	RequestID  string
+}
+
+func (e *GitHubAPIError) Error() string {
+	msg := "GitHub API error: " + e.Status
+	// Evita "404 Not Found: Not Found".
+	if e.Message != "" && !strings.HasSuffix(e.Status, e.Message) {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id: %s)", e.RequestID)
+	}
+	return msg
+}
+
+// maxErrorBodySize limita quanto do corpo de uma resposta de erro é lido em
+// busca da mensagem.
+const maxErrorBodySize = 64 << 10
+
+// apiError monta o *GitHubAPIError de uma resposta com status inesperado, com
+// a mensagem do corpo e o request id quando presentes.
+func apiError(resp *http.Response) error {
+	err := &GitHubAPIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RequestID:  requestID(resp),
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if json.Unmarshal(data, &body) == nil {
+		err.Message = strings.TrimSpace(body.Message)
+	}
+	return err
+}
+
+func (gc *GitHubClient) GetUser(ctx context.Context, username string) (*GitHubUser, error) {
+	user, _, err := gc.getUser(ctx, username)
+	return user, err
+}
+
+// getUser é GetUser devolvendo também os cabeçalhos da resposta.
+func (gc *GitHubClient) getUser(ctx context.Context, username string) (*GitHubUser, http.Header, error) {
+	endpoint := "/users/" + username
+	if username == "" {
+		endpoint = "/user"
+	}
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, apiError(resp)
+	}
+
+	var user GitHubUser
+	if err := decodeJSON(resp.Body, &user); err != nil {
+		return nil, nil, err
+	}
+
+	return &user, resp.Header, nil
+}
+
+// GetTokenInfo retorna o usuário autenticado junto com o que os cabeçalhos da
+// resposta revelam sobre o token: escopos e limite de requisições.
+func (gc *GitHubClient) GetTokenInfo(ctx context.Context) (*GitHubTokenInfo, error) {
+	user, header, err := gc.getUser(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &GitHubTokenInfo{User: *user}
+
+	// Tokens fine-grained não têm escopos OAuth e não recebem X-OAuth-Scopes.
+	scopes, hasScopes := header["X-Oauth-Scopes"]
+	info.FineGrained = strings.HasPrefix(gc.token, "github_pat_") || !hasScopes
+	if hasScopes && len(scopes) > 0 {
+		for _, scope := range strings.Split(scopes[0], ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+
+	info.RateLimitLimit, _ = strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	info.RateLimitRemaining, _ = strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		info.RateLimitReset = time.Unix(reset, 0)
+	}
+
+	return info, nil
+}
+
+func (gc *GitHubClient) GetRepos(ctx context.Context, username string, opts PageOptions) ([]GitHubRepo, error) {
+	endpoint := "/users/" + username + "/repos"
+	if username == "" {
+		endpoint = "/user/repos"
+	}
+	query := gc.listQuery()
+	opts.apply(query)
+	endpoint += "?" + query.Encode()
+
+	var repos []GitHubRepo
+	err := gc.getList(ctx, endpoint, mediaType("topics"), opts, func(body io.Reader) error {
+		var page []GitHubRepo
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		repos = append(repos, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+func (gc *GitHubClient) GetOrg(ctx context.Context, org string) (*GitHubOrg, error) {
+	endpoint := "/orgs/" + org
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var organization GitHubOrg
+	if err := decodeJSON(resp.Body, &organization); err != nil {
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+// GetOrgReposPage retorna só a primeira página dos repositórios da
+// organização, sem percorrer as demais.
+func (gc *GitHubClient) GetOrgReposPage(ctx context.Context, org string) ([]GitHubRepo, error) {
+	endpoint := "/orgs/" + org + "/repos?" + gc.listQuery().Encode()
+
+	resp, err := gc.makeRequestWithAccept(ctx, "GET", endpoint, mediaType("topics"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var repos []GitHubRepo
+	if err := decodeJSON(resp.Body, &repos); err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// IssueFilter reúne os filtros opcionais de GetIssues. Campos vazios não são
+// enviados.
+type IssueFilter struct {
+	// Milestone aceita o número do milestone, "*" (qualquer) ou "none".
+	Milestone string
+	// Since restringe às issues atualizadas nesse instante ou depois
+	// (ISO 8601, ex.: 2024-01-31T12:00:00Z).
+	Since string
+	// State aceita "open" (padrão da API), "closed" ou "all".
+	State string
+	// Labels são nomes de labels separados por vírgula; a issue precisa ter
+	// todos eles.
+	Labels string
+	// IncludePullRequests mantém no resultado os pull requests, que o
+	// endpoint de issues devolve junto com as issues. Não vai para a query:
+	// o filtro é feito depois da busca.
+	IncludePullRequests bool
+}
+
+func (f IssueFilter) apply(query url.Values) {
+	if f.Milestone != "" {
+		query.Set("milestone", f.Milestone)
+	}
+	if f.State != "" {
+		query.Set("state", f.State)
+	}
+	if f.Labels != "" {
+		query.Set("labels", f.Labels)
+	}
+	if f.Since != "" {
+		query.Set("since", f.Since)
+	}
+}
+
+// parseSince valida um timestamp ISO 8601 e o normaliza para o formato
+// aceito pela API. Datas sem hora (2024-01-31) valem desde a meia-noite UTC.
+func parseSince(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("since inválido: %q (use ISO 8601, ex.: 2024-01-31T12:00:00Z ou 2024-01-31)", value)
+}
+
+// issueStates são os valores aceitos no filtro state de get_issues.
+var issueStates = []string{"open", "closed", "all"}
+
+func validateIssueState(state string) error {
+	for _, allowed := range issueStates {
+		if state == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("state inválido: %q (use open, closed ou all)", state)
+}
+
+// normalizeLabels limpa uma lista de labels separada por vírgulas, removendo
+// espaços e itens vazios.
+func normalizeLabels(value string) string {
+	var labels []string
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, ",")
+}
+
+func validateMilestone(milestone string) error {
+	if milestone == "" || milestone == "*" || milestone == "none" {
+		return nil
+	}
+	if n, err := strconv.Atoi(milestone); err != nil || n < 1 {
+		return fmt.Errorf("milestone inválido: %q (use o número do milestone, * ou none)", milestone)
+	}
+	return nil
+}
+
+func (gc *GitHubClient) GetIssues(ctx context.Context, owner, repo string, filter IssueFilter, opts PageOptions) ([]GitHubIssue, error) {
+	query := gc.listQuery()
+	filter.apply(query)
+	opts.apply(query)
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues?%s", owner, repo, query.Encode())
+
+	var issues []GitHubIssue
+	err := gc.getList(ctx, endpoint, mediaType("default"), opts, func(body io.Reader) error {
+		var page []GitHubIssue
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		for _, issue := range page {
+			if issue.PullRequest == nil || filter.IncludePullRequests {
+				issues = append(issues, issue)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+func (gc *GitHubClient) GetPullRequests(ctx context.Context, owner, repo string, opts PageOptions) ([]GitHubPR, error) {
+	query := gc.listQuery()
+	opts.apply(query)
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls?%s", owner, repo, query.Encode())
+
+	var prs []GitHubPR
+	err := gc.getList(ctx, endpoint, mediaType("default"), opts, func(body io.Reader) error {
+		var page []GitHubPR
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		prs = append(prs, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prs, nil
+}
+
+func (gc *GitHubClient) GetCommits(ctx context.Context, owner, repo, ref string, opts PageOptions) ([]GitHubCommit, error) {
+	query := gc.listQuery()
+	if ref != "" {
+		query.Set("sha", ref)
+	}
+	opts.apply(query)
+	endpoint := fmt.Sprintf("/repos/%s/%s/commits?%s", owner, repo, query.Encode())
+
+	var commits []GitHubCommit
+	err := gc.getList(ctx, endpoint, mediaType("default"), opts, func(body io.Reader) error {
+		var page []GitHubCommit
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		commits = append(commits, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+func (gc *GitHubClient) GetContent(ctx context.Context, owner, repo, path string) (*GitHubContent, error) {
+	return gc.GetContentAtRef(ctx, owner, repo, path, "")
+}
+
+// GetContentAtRef é GetContent lendo o arquivo em ref (branch, tag ou SHA);
+// ref vazio usa a branch padrão. Se path for um diretório, o erro indica
+// list_directory.
+func (gc *GitHubClient) GetContentAtRef(ctx context.Context, owner, repo, path, ref string) (*GitHubContent, error) {
+	data, err := gc.getContents(ctx, owner, repo, path, ref)
+	if err != nil {
+		return nil, err
+	}
+	if isJSONArray(data) {
+		return nil, fmt.Errorf("%s é um diretório; use list_directory para listar o conteúdo", path)
+	}
+
+	var content GitHubContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// GetDirectory lista as entradas (nome, tipo, tamanho) do diretório path em
+// ref; path vazio é a raiz e ref vazio usa a branch padrão. A API devolve no
+// máximo 1000 entradas por diretório.
+func (gc *GitHubClient) GetDirectory(ctx context.Context, owner, repo, path, ref string) ([]GitHubContent, error) {
+	data, err := gc.getContents(ctx, owner, repo, path, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !isJSONArray(data) {
+		var content GitHubContent
+		if err := json.Unmarshal(data, &content); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s não é um diretório (tipo %s)", path, content.Type)
+	}
+
+	var entries []GitHubContent
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// getContents busca /contents/{path} e devolve o corpo ainda sem decodificar:
+// um objeto para arquivos e um array para diretórios.
+func (gc *GitHubClient) getContents(ctx context.Context, owner, repo, path, ref string) (json.RawMessage, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/contents/%s", owner, repo, strings.Trim(path, "/"))
+	if ref != "" {
+		endpoint += "?ref=" + url.QueryEscape(ref)
+	}
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var data json.RawMessage
+	if err := decodeJSON(resp.Body, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// isJSONArray diz se data é um array JSON, olhando o primeiro byte que não é
+// espaço.
+func isJSONArray(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// decodeContent devolve o texto de um arquivo obtido por GetContent. A API
+// envia o conteúdo em base64 quebrado em linhas.
+func decodeContent(content *GitHubContent) (string, error) {
+	if content.Encoding != "base64" {
+		return content.Content, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content.Content, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("conteúdo base64 inválido em %s: %v", content.Path, err)
+	}
+	return string(data), nil
+}
+
+// isBinary trata como binário o conteúdo que não é UTF-8 válido ou que tem
+// bytes nulos, que nenhum arquivo de texto usa.
+func isBinary(text string) bool {
+	return strings.IndexByte(text, 0) >= 0 || !utf8.ValidString(text)
+}
+
+// GetPullRequestDiff retorna o diff unificado de um pull request. O corpo da
+// resposta é texto puro, não JSON.
+func (gc *GitHubClient) GetPullRequestDiff(ctx context.Context, owner, repo string, number int) (string, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, number)
+
+	resp, err := gc.makeRequestWithAccept(ctx, "GET", endpoint, mediaType("diff"), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(resp)
+	}
+
+	diff, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(diff), nil
+}
+
+// GetCodeFrequency retorna as adições e remoções semanais do repositório.
+func (gc *GitHubClient) GetCodeFrequency(ctx context.Context, owner, repo string) ([]GitHubCodeFrequency, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/stats/code_frequency", owner, repo)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	// Cada semana vem como [timestamp, adições, remoções].
+	var weeks [][3]int64
+	if err := decodeJSON(resp.Body, &weeks); err != nil {
+		return nil, err
+	}
+
+	frequency := make([]GitHubCodeFrequency, 0, len(weeks))
+	for _, week := range weeks {
+		frequency = append(frequency, GitHubCodeFrequency{
+			Week:      week[0],
+			Additions: int(week[1]),
+			Deletions: int(-week[2]),
+		})
+	}
+
+	return frequency, nil
+}
+
+// GetAssignees lista todos os usuários que podem ser atribuídos a issues do
+// repositório.
+func (gc *GitHubClient) GetAssignees(ctx context.Context, owner, repo string) ([]GitHubUser, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/assignees?%s", owner, repo, gc.listQuery().Encode())
+
+	var assignees []GitHubUser
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubUser
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		assignees = append(assignees, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assignees, nil
+}
+
+// GetBranches lista todas as branches do repositório, com o SHA da ponta e
+// se a branch é protegida.
+func (gc *GitHubClient) GetBranches(ctx context.Context, owner, repo string) ([]GitHubBranch, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/branches?%s", owner, repo, gc.listQuery().Encode())
+
+	var branches []GitHubBranch
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubBranch
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		branches = append(branches, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return branches, nil
+}
+
+// CreateIssue abre uma issue e retorna a issue criada.
+func (gc *GitHubClient) CreateIssue(ctx context.Context, owner, repo, title, body string, labels []string) (*GitHubIssue, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues", owner, repo)
+
+	fields := map[string]interface{}{"title": title, "body": body}
+	if len(labels) > 0 {
+		fields["labels"] = labels
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, apiError(resp)
+	}
+
+	var issue GitHubIssue
+	if err := decodeJSON(resp.Body, &issue); err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
+
+// GetIssueTemplate retorna o texto de um template de issue em
+// .github/ISSUE_TEMPLATE/, aceitando o nome com ou sem a extensão .md. Devolve
+// "" sem erro quando o template não existe.
+func (gc *GitHubClient) GetIssueTemplate(ctx context.Context, owner, repo, name string) (string, error) {
+	candidates := []string{name}
+	if !strings.HasSuffix(name, ".md") {
+		candidates = append(candidates, name+".md")
+	}
+
+	for _, candidate := range candidates {
+		endpoint := fmt.Sprintf("/repos/%s/%s/contents/.github/ISSUE_TEMPLATE/%s", owner, repo, url.PathEscape(candidate))
+
+		resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return "", err
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return "", apiError(resp)
+		}
+
+		var content GitHubContent
+		err = decodeJSON(resp.Body, &content)
+		resp.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		return decodeContent(&content)
+	}
+
+	return "", nil
+}
+
+// AddAssignees atribui usuários a uma issue e retorna a issue atualizada.
+func (gc *GitHubClient) AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*GitHubIssue, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/%d/assignees", owner, repo, number)
+
+	payload, err := json.Marshal(map[string]interface{}{"assignees": assignees})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, apiError(resp)
+	}
+
+	var issue GitHubIssue
+	if err := decodeJSON(resp.Body, &issue); err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
+
+// GetIssueEvents lista os eventos de uma issue ou pull request.
+func (gc *GitHubClient) GetIssueEvents(ctx context.Context, owner, repo string, number int) ([]GitHubIssueEvent, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/%d/events?%s", owner, repo, number, gc.listQuery().Encode())
+
+	var events []GitHubIssueEvent
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubIssueEvent
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		events = append(events, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ListRunArtifacts lista os artefatos de uma execução de workflow.
+func (gc *GitHubClient) ListRunArtifacts(ctx context.Context, owner, repo string, runID int64) ([]GitHubArtifact, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/actions/runs/%d/artifacts?%s", owner, repo, runID, gc.listQuery().Encode())
+
+	var artifacts []GitHubArtifact
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page struct {
+			Artifacts []GitHubArtifact `json:"artifacts"`
+		}
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		artifacts = append(artifacts, page.Artifacts...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts, nil
+}
+
+// DownloadArtifact grava em w o zip do artefato e devolve quantos bytes foram
+// escritos. A API responde com um redirect para uma URL temporária, que o
+// http.Client segue sem repassar o token.
+func (gc *GitHubClient) DownloadArtifact(ctx context.Context, owner, repo string, artifactID int64, w io.Writer) (int64, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/actions/artifacts/%d/zip", owner, repo, artifactID)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, apiError(resp)
+	}
+
+	return io.Copy(w, resp.Body)
+}
+
+// ListRulesets lista os rulesets de um repositório, inclusive os herdados da
+// organização.
+func (gc *GitHubClient) ListRulesets(ctx context.Context, owner, repo string) ([]GitHubRuleset, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/rulesets?%s", owner, repo, gc.listQuery().Encode())
+
+	var rulesets []GitHubRuleset
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubRuleset
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		rulesets = append(rulesets, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rulesets, nil
+}
+
+// GetRuleset retorna um ruleset com suas condições e regras.
+func (gc *GitHubClient) GetRuleset(ctx context.Context, owner, repo string, id int64) (*GitHubRuleset, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/rulesets/%d", owner, repo, id)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var ruleset GitHubRuleset
+	if err := decodeJSON(resp.Body, &ruleset); err != nil {
+		return nil, err
+	}
+
+	return &ruleset, nil
+}
+
+// GetStargazers lista as estrelas do repositório com data, das mais antigas
+// para as mais novas, lendo no máximo maxPages páginas (0 = todas). O bool
+// indica se a lista foi cortada pelo limite.
+func (gc *GitHubClient) GetStargazers(ctx context.Context, owner, repo string, maxPages int) ([]GitHubStargazer, bool, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/stargazers?%s", owner, repo, gc.listQuery().Encode())
+
+	var stargazers []GitHubStargazer
+	capped, err := gc.getPages(ctx, endpoint, mediaType("stars"), maxPages, func(body io.Reader) error {
+		var page []GitHubStargazer
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		stargazers = append(stargazers, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return stargazers, capped, nil
+}
+
+// ListEnvironments lista os ambientes de deploy do repositório.
+func (gc *GitHubClient) ListEnvironments(ctx context.Context, owner, repo string) ([]GitHubEnvironment, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/environments?%s", owner, repo, gc.listQuery().Encode())
+
+	var environments []GitHubEnvironment
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page struct {
+			Environments []GitHubEnvironment `json:"environments"`
+		}
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		environments = append(environments, page.Environments...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return environments, nil
+}
+
+// GetRepoActivity lista a atividade mais recente do repositório (uma página,
+// da mais nova para a mais antiga), opcionalmente só de um activityType.
+func (gc *GitHubClient) GetRepoActivity(ctx context.Context, owner, repo string, activityType string) ([]GitHubActivity, error) {
+	query := gc.listQuery()
+	if activityType != "" {
+		query.Set("activity_type", activityType)
+	}
+	endpoint := fmt.Sprintf("/repos/%s/%s/activity?%s", owner, repo, query.Encode())
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var activity []GitHubActivity
+	if err := decodeJSON(resp.Body, &activity); err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// GetCheckSuites lista as check suites de uma ref (branch, tag ou SHA).
+func (gc *GitHubClient) GetCheckSuites(ctx context.Context, owner, repo, ref string) ([]GitHubCheckSuite, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/commits/%s/check-suites?%s", owner, repo, url.PathEscape(ref), gc.listQuery().Encode())
+
+	var suites []GitHubCheckSuite
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page GitHubCheckSuites
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		suites = append(suites, page.CheckSuites...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return suites, nil
+}
+
+// GetPages retorna a configuração do GitHub Pages do repositório, ou nil se o
+// Pages não estiver habilitado (404).
+func (gc *GitHubClient) GetPages(ctx context.Context, owner, repo string) (*GitHubPages, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/pages", owner, repo)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var pages GitHubPages
+	if err := decodeJSON(resp.Body, &pages); err != nil {
+		return nil, err
+	}
+
+	return &pages, nil
+}
+
+// UpdatePullRequest altera o estado de um pull request: "closed" fecha sem
+// fazer merge e "open" reabre.
+func (gc *GitHubClient) UpdatePullRequest(ctx context.Context, owner, repo string, number int, state string) (*GitHubPR, error) {
+	if state != "open" && state != "closed" {
+		return nil, fmt.Errorf("estado inválido: %q (use open ou closed)", state)
+	}
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, number)
+
+	payload, err := json.Marshal(map[string]string{"state": state})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "PATCH", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var pr GitHubPR
+	if err := decodeJSON(resp.Body, &pr); err != nil {
+		return nil, err
+	}
+
+	return &pr, nil
+}
+
+// UpdateIssueState fecha ("closed") ou reabre ("open") uma issue.
+func (gc *GitHubClient) UpdateIssueState(ctx context.Context, owner, repo string, number int, state string) (*GitHubIssue, error) {
+	return gc.UpdateIssueStateWithReason(ctx, owner, repo, number, state, "")
+}
+
+// issueStateReasons são os state_reason aceitos pela API para cada estado.
+var issueStateReasons = map[string][]string{
+	"closed": {"completed", "not_planned"},
+	"open":   {"reopened"},
+}
+
+// UpdateIssueStateWithReason é como UpdateIssueState, mas envia também o
+// state_reason (completed ou not_planned ao fechar, reopened ao reabrir);
+// vazio deixa o motivo a cargo do GitHub.
+func (gc *GitHubClient) UpdateIssueStateWithReason(ctx context.Context, owner, repo string, number int, state, reason string) (*GitHubIssue, error) {
+	if state != "open" && state != "closed" {
+		return nil, fmt.Errorf("estado inválido: %q (use open ou closed)", state)
+	}
+	if reason != "" && !containsString(issueStateReasons[state], reason) {
+		return nil, fmt.Errorf("state_reason inválido para %s: %q (use %s)", state, reason, strings.Join(issueStateReasons[state], " ou "))
+	}
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, number)
+
+	fields := map[string]string{"state": state}
+	if reason != "" {
+		fields["state_reason"] = reason
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "PATCH", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var issue GitHubIssue
+	if err := decodeJSON(resp.Body, &issue); err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
+
+// RequestReviewers pede revisão de um pull request a usuários e/ou times
+// (pelo slug) e devolve a lista resultante de revisores pendentes.
+func (gc *GitHubClient) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers, teamReviewers []string) (*GitHubReviewRequest, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls/%d/requested_reviewers", owner, repo, number)
+
+	body := map[string][]string{}
+	if len(reviewers) > 0 {
+		body["reviewers"] = reviewers
+	}
+	if len(teamReviewers) > 0 {
+		body["team_reviewers"] = teamReviewers
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return nil, fmt.Errorf("%w: revisores precisam ser colaboradores do repositório e não podem ser o autor do PR", apiError(resp))
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var request GitHubReviewRequest
+	if err := decodeJSON(resp.Body, &request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// GetIssueComments lista, em ordem cronológica, os comentários da issue ou do
+// PR number (só a conversa; comentários de revisão no código ficam de fora).
+func (gc *GitHubClient) GetIssueComments(ctx context.Context, owner, repo string, number int) ([]GitHubIssueComment, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/%d/comments?%s", owner, repo, number, gc.listQuery().Encode())
+
+	var comments []GitHubIssueComment
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubIssueComment
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// CreateIssueComment comenta na issue ou no PR number e retorna o comentário
+// criado.
+func (gc *GitHubClient) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) (*GitHubIssueComment, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, number)
+
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, apiError(resp)
+	}
+
+	var comment GitHubIssueComment
+	if err := decodeJSON(resp.Body, &comment); err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// UpdateIssueComment substitui o texto de um comentário de issue ou PR.
+func (gc *GitHubClient) UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) (*GitHubIssueComment, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", owner, repo, commentID)
+
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "PATCH", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var comment GitHubIssueComment
+	if err := decodeJSON(resp.Body, &comment); err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// DeleteIssueComment remove um comentário de issue ou PR.
+func (gc *GitHubClient) DeleteIssueComment(ctx context.Context, owner, repo string, commentID int64) error {
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", owner, repo, commentID)
+
+	resp, err := gc.makeRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return apiError(resp)
+	}
+
+	return nil
+}
+
+// GetTree retorna a árvore de ref (branch, tag ou SHA); com recursive, inclui
+// todas as subárvores em uma única lista.
+func (gc *GitHubClient) GetTree(ctx context.Context, owner, repo, ref string, recursive bool) (*GitHubTree, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/git/trees/%s", owner, repo, url.PathEscape(ref))
+	if recursive {
+		endpoint += "?recursive=1"
+	}
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var tree GitHubTree
+	if err := decodeJSON(resp.Body, &tree); err != nil {
+		return nil, err
+	}
+
+	return &tree, nil
+}
+
+// GetPullRequestFiles lista os arquivos alterados em um pull request, com o
+// patch de cada um. O GitHub limita a listagem a 3000 arquivos.
+func (gc *GitHubClient) GetPullRequestFiles(ctx context.Context, owner, repo string, number int) ([]GitHubFile, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls/%d/files?%s", owner, repo, number, gc.listQuery().Encode())
+
+	var files []GitHubFile
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubFile
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		files = append(files, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// GetZen retorna um aforismo aleatório do GitHub em texto puro. Só exige um
+// token válido, sem escopos, e serve como teste mínimo de conectividade.
+func (gc *GitHubClient) GetZen(ctx context.Context) (string, error) {
+	resp, err := gc.makeRequest(ctx, "GET", "/zen", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(resp)
+	}
+
+	zen, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(zen)), nil
+}
+
+// RenderMarkdown converte text em HTML como o GitHub renderiza. mode é
+// "markdown" ou "gfm"; repoContext (owner/repo) resolve referências como
+// #123 no modo gfm.
+func (gc *GitHubClient) RenderMarkdown(ctx context.Context, text, mode, repoContext string) (string, error) {
+	fields := map[string]string{"text": text}
+	if mode != "" {
+		fields["mode"] = mode
+	}
+	if repoContext != "" {
+		fields["context"] = repoContext
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := gc.makeRequest(ctx, "POST", "/markdown", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(resp)
+	}
+
+	html, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(html), nil
+}
+
+// AddReaction adiciona uma reação à issue ou pull request number. Se o
+// usuário já tiver reagido com o mesmo conteúdo, a reação existente é
+// retornada.
+func (gc *GitHubClient) AddReaction(ctx context.Context, owner, repo string, issueNumber int, content string) (*GitHubReaction, error) {
+	if !validReaction(content) {
+		return nil, fmt.Errorf("reação inválida: %q (use %s)", content, strings.Join(reactionContents, ", "))
+	}
+	endpoint := fmt.Sprintf("/repos/%s/%s/issues/%d/reactions", owner, repo, issueNumber)
+
+	payload, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequestWithAccept(ctx, "POST", endpoint, mediaType("reactions"), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var reaction GitHubReaction
+	if err := decodeJSON(resp.Body, &reaction); err != nil {
+		return nil, err
+	}
+
+	return &reaction, nil
+}
+
+func validReaction(content string) bool {
+	for _, c := range reactionContents {
+		if c == content {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFollowers lista os seguidores de um usuário (ou do usuário autenticado
+// quando username é vazio).
+func (gc *GitHubClient) GetFollowers(ctx context.Context, username string) ([]GitHubUser, error) {
+	return gc.getUserList(ctx, username, "followers")
+}
+
+// GetFollowing lista quem o usuário segue (ou quem o usuário autenticado
+// segue quando username é vazio).
+func (gc *GitHubClient) GetFollowing(ctx context.Context, username string) ([]GitHubUser, error) {
+	return gc.getUserList(ctx, username, "following")
+}
+
+func (gc *GitHubClient) getUserList(ctx context.Context, username, relation string) ([]GitHubUser, error) {
+	endpoint := "/users/" + username + "/" + relation
+	if username == "" {
+		endpoint = "/user/" + relation
+	}
+	endpoint += "?" + gc.listQuery().Encode()
+
+	var users []GitHubUser
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubUser
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		users = append(users, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetRepo retorna os detalhes de um repositório.
+func (gc *GitHubClient) GetRepo(ctx context.Context, owner, repo string) (*GitHubRepo, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s", owner, repo)
+
+	resp, err := gc.makeRequestWithAccept(ctx, "GET", endpoint, mediaType("topics"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var repository GitHubRepo
+	if err := decodeJSON(resp.Body, &repository); err != nil {
+		return nil, err
+	}
+
+	return &repository, nil
+}
+
+// GetLanguages retorna os bytes de código por linguagem do repositório.
+func (gc *GitHubClient) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/languages", owner, repo)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var languages map[string]int
+	if err := decodeJSON(resp.Body, &languages); err != nil {
+		return nil, err
+	}
+
+	return languages, nil
+}
+
+// GetLatestRelease retorna a release publicada mais recente. Retorna nil sem
+// erro quando o repositório não tem releases.
+func (gc *GitHubClient) GetLatestRelease(ctx context.Context, owner, repo string) (*GitHubRelease, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/releases/latest", owner, repo)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var release GitHubRelease
+	if err := decodeJSON(resp.Body, &release); err != nil {
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// GetReleases lista todas as releases do repositório, da mais recente para a
+// mais antiga; rascunhos só aparecem para quem tem acesso de escrita.
+func (gc *GitHubClient) GetReleases(ctx context.Context, owner, repo string) ([]GitHubRelease, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/releases?%s", owner, repo, gc.listQuery().Encode())
+
+	var releases []GitHubRelease
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubRelease
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		releases = append(releases, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// CountOpenIssues conta as issues abertas (sem pull requests) usando a API de
+// busca.
+func (gc *GitHubClient) CountOpenIssues(ctx context.Context, owner, repo string) (int, error) {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf("repo:%s/%s type:issue state:open", owner, repo))
+	query.Set("per_page", "1")
+
+	resp, err := gc.makeRequest(ctx, "GET", "/search/issues?"+query.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, apiError(resp)
+	}
+
+	var result struct {
+		TotalCount int `json:"total_count"`
+	}
+	if err := decodeJSON(resp.Body, &result); err != nil {
+		return 0, err
+	}
+
+	return result.TotalCount, nil
+}
+
+// ListRepoInvitations lista os convites pendentes para colaborar no
+// repositório.
+func (gc *GitHubClient) ListRepoInvitations(ctx context.Context, owner, repo string) ([]GitHubInvitation, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/invitations?%s", owner, repo, gc.listQuery().Encode())
+
+	var invitations []GitHubInvitation
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page []GitHubInvitation
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		invitations = append(invitations, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// Valores aceitos em sort e order por SearchRepositories; vazio usa a
+// ordenação por relevância da API.
+var (
+	repoSearchSorts  = []string{"stars", "forks", "updated"}
+	repoSearchOrders = []string{"asc", "desc"}
+)
+
+// SearchRepositories busca repositórios pela sintaxe de busca do GitHub
+// (ex.: "mcp language:go stars:>100") e devolve a primeira página.
+func (gc *GitHubClient) SearchRepositories(ctx context.Context, q, sort, order string) ([]GitHubRepo, error) {
+	if sort != "" && !containsString(repoSearchSorts, sort) {
+		return nil, fmt.Errorf("sort inválido: %q (use stars, forks ou updated)", sort)
+	}
+	if order != "" && !containsString(repoSearchOrders, order) {
+		return nil, fmt.Errorf("order inválido: %q (use asc ou desc)", order)
+	}
+
+	query := gc.listQuery()
+	query.Set("q", q)
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	if order != "" {
+		query.Set("order", order)
+	}
+
+	resp, err := gc.makeRequest(ctx, "GET", "/search/repositories?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var result struct {
+		Items []GitHubRepo `json:"items"`
+	}
+	if err := decodeJSON(resp.Body, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Items, nil
+}
+
+// GitHubCodeSearch é o envelope devolvido por /search/code.
+type GitHubCodeSearch struct {
+	TotalCount        int                `json:"total_count"`
+	IncompleteResults bool               `json:"incomplete_results"`
+	Items             []GitHubCodeResult `json:"items"`
+}
+
+// SearchCode executa uma busca de código pedindo os trechos encontrados
+// (text-match). q segue a sintaxe da busca do GitHub e pode trazer
+// qualificadores (repo:, language:, path:...); a codificação fica a cargo
+// desta função.
+func (gc *GitHubClient) SearchCode(ctx context.Context, q string) (*GitHubCodeSearch, error) {
+	query := gc.listQuery()
+	query.Set("q", q)
+
+	resp, err := gc.makeRequestWithAccept(ctx, "GET", "/search/code?"+query.Encode(), mediaType("text_match"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var result GitHubCodeSearch
+	if err := decodeJSON(resp.Body, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GrepRepo procura um termo nos arquivos de um único repositório usando a
+// busca de código do GitHub.
+func (gc *GitHubClient) GrepRepo(ctx context.Context, owner, repo, term string) (*GitHubCodeSearch, error) {
+	return gc.SearchCode(ctx, fmt.Sprintf("%s repo:%s/%s", term, owner, repo))
+}
+
+// GetCommitSHA resolve uma ref (branch, tag ou SHA abreviado) para o SHA
+// completo do commit.
+func (gc *GitHubClient) GetCommitSHA(ctx context.Context, owner, repo, ref string) (string, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/commits/%s", owner, repo, url.PathEscape(ref))
+
+	resp, err := gc.makeRequestWithAccept(ctx, "GET", endpoint, mediaType("sha"), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(resp)
+	}
+
+	sha, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(sha)), nil
+}
+
+// ResolveRef resolve ref para o SHA completo (40 caracteres) do commit,
+// tentando nesta ordem uma branch, uma tag (tags anotadas são seguidas até o
+// commit) e, por fim, um SHA, completo ou abreviado.
+func (gc *GitHubClient) ResolveRef(ctx context.Context, owner, repo, ref string) (string, error) {
+	sha, _, err := gc.resolveRef(ctx, owner, repo, ref)
+	return sha, err
+}
+
+// resolveRef é ResolveRef devolvendo também o que ref era: "branch", "tag"
+// ou "commit".
+func (gc *GitHubClient) resolveRef(ctx context.Context, owner, repo, ref string) (string, string, error) {
+	for _, kind := range []struct{ prefix, name string }{{"heads/", "branch"}, {"tags/", "tag"}} {
+		sha, err := gc.resolveGitRef(ctx, owner, repo, kind.prefix+ref)
+		if err != nil {
+			return "", "", err
+		}
+		if sha != "" {
+			return sha, kind.name, nil
+		}
+	}
+
+	notFound := fmt.Errorf("ref %q não corresponde a nenhuma branch, tag ou commit de %s/%s", ref, owner, repo)
+	if !commitSHAPattern.MatchString(ref) {
+		return "", "", notFound
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/%s/commits/%s", owner, repo, url.PathEscape(ref))
+	resp, err := gc.makeRequestWithAccept(ctx, "GET", endpoint, mediaType("sha"), nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	// SHA inexistente vem como 404; SHA abreviado ambíguo ou inválido, 422.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity {
+		return "", "", notFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", apiError(resp)
+	}
+
+	sha, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(string(sha)), "commit", nil
+}
+
+// maxTagDepth limita quantas tags anotadas encadeadas resolveGitRef segue.
+const maxTagDepth = 5
+
+// resolveGitRef devolve o SHA do commit apontado pela ref completa (ex.:
+// heads/main, tags/v1.0), ou "" se a ref não existir.
+func (gc *GitHubClient) resolveGitRef(ctx context.Context, owner, repo, ref string) (string, error) {
+	var object GitHubGitObject
+	found, err := gc.getGitObject(ctx, fmt.Sprintf("/repos/%s/%s/git/ref/%s", owner, repo, ref), &object)
+	if err != nil || !found {
+		return "", err
+	}
+
+	for depth := 0; object.Object.Type == "tag"; depth++ {
+		if depth == maxTagDepth {
+			return "", fmt.Errorf("ref %s: mais de %d tags anotadas encadeadas", ref, maxTagDepth)
+		}
+		found, err := gc.getGitObject(ctx, fmt.Sprintf("/repos/%s/%s/git/tags/%s", owner, repo, object.Object.SHA), &object)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", fmt.Errorf("ref %s: tag anotada %s não encontrada", ref, object.Object.SHA)
+		}
+	}
+	if object.Object.Type != "commit" {
+		return "", fmt.Errorf("ref %s aponta para um %s, não para um commit", ref, object.Object.Type)
+	}
+
+	return object.Object.SHA, nil
+}
+
+// getGitObject decodifica endpoint em object; devolve false em 404.
+func (gc *GitHubClient) getGitObject(ctx context.Context, endpoint string, object *GitHubGitObject) (bool, error) {
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, apiError(resp)
+	}
+
+	return true, decodeJSON(resp.Body, object)
+}
+
+// SetRepoVisibility muda a visibilidade do repositório (public, private ou
+// internal) e retorna o repositório atualizado.
+func (gc *GitHubClient) SetRepoVisibility(ctx context.Context, owner, repo, visibility string) (*GitHubRepo, error) {
+	if !validVisibility(visibility) {
+		return nil, fmt.Errorf("visibilidade inválida: %q (use public, private ou internal)", visibility)
+	}
+	endpoint := fmt.Sprintf("/repos/%s/%s", owner, repo)
+
+	payload, err := json.Marshal(map[string]string{"visibility": visibility})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "PATCH", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var repository GitHubRepo
+	if err := decodeJSON(resp.Body, &repository); err != nil {
+		return nil, err
+	}
+
+	return &repository, nil
+}
+
+func validVisibility(visibility string) bool {
+	return visibility == "public" || visibility == "private" || visibility == "internal"
+}
+
+// CompareCommits compara duas refs, retornando os commits de head que não
+// estão em base e os arquivos alterados.
+func (gc *GitHubClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (*GitHubComparison, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/compare/%s...%s", owner, repo, url.PathEscape(base), url.PathEscape(head))
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var comparison GitHubComparison
+	if err := decodeJSON(resp.Body, &comparison); err != nil {
+		return nil, err
+	}
+
+	return &comparison, nil
+}
+
+// GetCommitDetail retorna os totais e os arquivos alterados de um commit. Em
+// commits com muitos arquivos a API pagina a lista (300 por página); todas
+// as páginas são percorridas.
+func (gc *GitHubClient) GetCommitDetail(ctx context.Context, owner, repo, sha string) (*GitHubCommitDetail, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/commits/%s", owner, repo, url.PathEscape(sha))
+
+	var detail *GitHubCommitDetail
+	err := gc.getAllPages(ctx, endpoint, func(body io.Reader) error {
+		var page GitHubCommitDetail
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		if detail == nil {
+			detail = &page
+			return nil
+		}
+		detail.Files = append(detail.Files, page.Files...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+// compareFileLimit é o máximo de arquivos que a API devolve em uma comparação;
+// acima disso a lista vem cortada e não há como paginar o restante.
+const compareFileLimit = 300
+
+// CompareCommitsPaged é como CompareCommits, mas percorre até maxPages
+// páginas (0 = todas), juntando commits e arquivos. O bool indica se parou
+// pelo limite de páginas.
+func (gc *GitHubClient) CompareCommitsPaged(ctx context.Context, owner, repo, base, head string, maxPages int) (*GitHubComparison, bool, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/compare/%s...%s?%s", owner, repo, url.PathEscape(base), url.PathEscape(head), gc.listQuery().Encode())
+
+	var comparison *GitHubComparison
+	seenFiles := make(map[string]bool)
+	capped, err := gc.getPages(ctx, endpoint, mediaType("default"), maxPages, func(body io.Reader) error {
+		var page GitHubComparison
+		if err := decodeJSON(body, &page); err != nil {
+			return err
+		}
+		if comparison == nil {
+			comparison = &GitHubComparison{
+				Status:       page.Status,
+				AheadBy:      page.AheadBy,
+				BehindBy:     page.BehindBy,
+				TotalCommits: page.TotalCommits,
+				HTMLURL:      page.HTMLURL,
+			}
+		}
+		comparison.Commits = append(comparison.Commits, page.Commits...)
+		// Páginas seguintes podem repetir a lista de arquivos.
+		for _, file := range page.Files {
+			if !seenFiles[file.Filename] {
+				seenFiles[file.Filename] = true
+				comparison.Files = append(comparison.Files, file)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return comparison, capped, nil
+}
+
+// GetSubscription retorna a inscrição do usuário autenticado no repositório,
+// ou nil quando ele não acompanha o repositório.
+func (gc *GitHubClient) GetSubscription(ctx context.Context, owner, repo string) (*GitHubSubscription, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/subscription", owner, repo)
+
+	resp, err := gc.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var subscription GitHubSubscription
+	if err := decodeJSON(resp.Body, &subscription); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// SetSubscription passa a acompanhar o repositório (subscribed) ou silencia
+// suas notificações (ignored).
+func (gc *GitHubClient) SetSubscription(ctx context.Context, owner, repo string, subscribed, ignored bool) (*GitHubSubscription, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/subscription", owner, repo)
+
+	payload, err := json.Marshal(map[string]bool{"subscribed": subscribed, "ignored": ignored})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gc.makeRequest(ctx, "PUT", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	var subscription GitHubSubscription
+	if err := decodeJSON(resp.Body, &subscription); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// DeleteSubscription deixa de acompanhar o repositório.
+func (gc *GitHubClient) DeleteSubscription(ctx context.Context, owner, repo string) error {
+	endpoint := fmt.Sprintf("/repos/%s/%s/subscription", owner, repo)
+
+	resp, err := gc.makeRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return apiError(resp)
+	}
+
+	return nil
+}
+
+// Identificação do servidor no initialize e em server_info.
+const (
+	serverName      = "GitHub MCP Server"
+	serverVersion   = "1.0.0"
+	protocolVersion = "2024-11-05"
+)
+
+// Servidor MCP
+//
+// Cada MCPServer atende uma única sessão. defaultClient usa o GITHUB_TOKEN do
+// processo; github é o cliente da sessão, que pode usar um token próprio
+// enviado no initialize.
+type MCPServer struct {
+	defaultClient *GitHubClient
+	github        *GitHubClient
+	tools         []Tool
+	format        string
+	pretty        bool
+	sanitize      bool
+	readOnly      bool
+	toolPrefix    string
+
+	// audit registra cada tools/call (AUDIT_FILE); nil desativa. clientName
+	// vem do clientInfo do initialize e identifica quem chamou.
+	audit      *log.Logger
+	clientName string
+
+	// initializeReceived indica que o cliente já enviou initialize; antes
+	// disso tools/list e tools/call são recusados.
+	initializeReceived bool
+
+	// notifications recebe as notificações MCP enviadas por Notify (webhooks
+	// do GitHub, mudanças em tools/list); Serve as escreve entre as
+	// respostas.
+	notifications chan MCPMessage
+
+	// maxResponseSize limita o texto somado de todos os resultados escritos
+	// de uma vez (uma resposta ou um lote); zero desativa.
+	maxResponseSize int
+
+	// idleTimeout encerra Serve quando nenhuma mensagem chega nesse
+	// intervalo; zero desativa.
+	idleTimeout time.Duration
+
+	// artifactDir é o único diretório em que list_run_artifacts grava
+	// downloads (ARTIFACT_DIR); vazio desativa os downloads.
+	artifactDir string
+}
+
+// writeTools são as ferramentas que alteram algo no GitHub; em modo somente
+// leitura (READ_ONLY=true) elas são recusadas.
+var writeTools = map[string]bool{
+	"assign_issue":               true,
+	"watch_repo":                 true,
+	"unwatch_repo":               true,
+	"add_reaction":               true,
+	"create_issue":               true,
+	"create_issue_from_template": true,
+	"close_issue":                true,
+	"reopen_issue":               true,
+	"set_repo_visibility":        true,
+	"close_pull_request":         true,
+	"request_reviewers":          true,
+	"create_issue_comment":       true,
+	"update_issue_comment":       true,
+	"delete_issue_comment":       true,
+}
+
+// NewMCPServer cria o servidor com um cliente para a API em baseURL (vazio
+// usa a API pública do GitHub).
+func NewMCPServer(token, baseURL string) *MCPServer {
+	client := NewGitHubClient(token, baseURL)
+	server := &MCPServer{
+		defaultClient:   client,
+		github:          client,
+		format:          formatPlain,
+		maxResponseSize: defaultMaxResponseSize,
+		notifications:   make(chan MCPMessage, notificationBuffer),
+		tools: []Tool{
+			{
 				Name:        "get_user",
 				Description: "Obter informações de um usuário GitHub",
 				InputSchema: map[string]interface{}{
@@ -298,479 +3110,6204 @@ func NewMCPServer(token string) *MCPServer {
 					"properties": map[string]interface{}{
 						"username": map[string]interface{}{
 							"type":        "string",
-							"description": "Nome do usuário (deixe vazio para usuário autenticado)",
+							"description": "Nome do usuário (deixe vazio para usuário autenticado)",
+						},
+						"output": outputProperty,
+					},
+				},
+				OutputSchema: outputSchemaOf(GitHubUser{}),
+			},
+			{
+				Name:        "whoami",
+				Description: "Mostrar o usuário autenticado, os escopos do token e o limite de requisições restante. Use como primeira chamada para saber o que o token permite",
+				InputSchema: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+				OutputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"login":                map[string]interface{}{"type": "string"},
+						"name":                 map[string]interface{}{"type": "string"},
+						"token_type":           map[string]interface{}{"type": "string", "enum": []string{"classic", "fine-grained"}},
+						"scopes":               arrayProp("Escopos do token clássico", "string"),
+						"rate_limit_limit":     map[string]interface{}{"type": "integer"},
+						"rate_limit_remaining": map[string]interface{}{"type": "integer"},
+						"rate_limit_reset":     map[string]interface{}{"type": "string", "description": "Horário de renovação (RFC 3339), vazio se desconhecido"},
+						"html_url":             map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"login", "token_type", "scopes", "rate_limit_limit", "rate_limit_remaining"},
+				},
+			},
+			{
+				Name:        "get_repos",
+				Description: "Listar repositórios de um usuário",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"username": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do usuário (deixe vazio para usuário autenticado)",
+						},
+						"page":     pageProperty,
+						"per_page": perPageProperty,
+						"all":      allPagesProperty,
+						"limit":    limitProperty,
+						"output":   outputProperty,
+					},
+				},
+				OutputSchema: outputSchemaOf([]GitHubRepo{}),
+			},
+			{
+				Name:        "get_repos_multi",
+				Description: "Listar repositórios de vários usuários em uma única chamada, agrupados por usuário",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"usernames": arrayProp("Nomes dos usuários", "string"),
+						"limit":     limitProperty,
+					},
+					"required": []string{"usernames"},
+				},
+			},
+			{
+				Name:        "get_issues",
+				Description: "Listar issues de um repositório. Pull requests, que a API devolve junto com as issues, ficam de fora, a menos que include_prs seja true",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"milestone": map[string]interface{}{
+							"type":        []string{"string", "integer"},
+							"description": "Filtrar por milestone: número, * (qualquer milestone) ou none (sem milestone)",
+						},
+						"since": map[string]interface{}{
+							"type":        "string",
+							"description": "Só issues atualizadas neste instante ou depois (ISO 8601, ex.: 2024-01-31T12:00:00Z)",
+						},
+						"state": map[string]interface{}{
+							"type":        "string",
+							"enum":        issueStates,
+							"description": "Estado das issues (padrão: open)",
+						},
+						"labels": map[string]interface{}{
+							"type":        "string",
+							"description": "Labels separadas por vírgula; só issues com todas elas (ex.: bug,ui)",
+						},
+						"include_prs": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Incluir também os pull requests (padrão: false)",
+						},
+						"raw": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Devolver o texto dos usuários sem sanitização mesmo com SANITIZE_BODIES=true",
+						},
+						"page":     pageProperty,
+						"per_page": perPageProperty,
+						"all":      allPagesProperty,
+						"limit":    limitProperty,
+						"output":   outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubIssue{}),
+			},
+			{
+				Name:        "get_pull_requests",
+				Description: "Listar pull requests de um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"raw": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Devolver o texto dos usuários sem sanitização mesmo com SANITIZE_BODIES=true",
+						},
+						"page":     pageProperty,
+						"per_page": perPageProperty,
+						"all":      allPagesProperty,
+						"limit":    limitProperty,
+						"output":   outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubPR{}),
+			},
+			{
+				Name:        "get_commits",
+				Description: "Listar commits de um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"raw": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Devolver o texto dos usuários sem sanitização mesmo com SANITIZE_BODIES=true",
+						},
+						"show_verification": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Mostrar se a assinatura de cada commit foi verificada",
+						},
+						"verified_only": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Listar só commits com assinatura verificada",
+						},
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA de onde partir (padrão: branch padrão)",
+						},
+						"page":     pageProperty,
+						"per_page": perPageProperty,
+						"all":      allPagesProperty,
+						"limit":    limitProperty,
+						"output":   outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubCommit{}),
+			},
+			{
+				Name:        "get_content",
+				Description: "Obter conteúdo de um arquivo no repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Caminho do arquivo",
+						},
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA (padrão: branch padrão)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "path"},
+				},
+				OutputSchema: outputSchemaOf(GitHubContent{}),
+			},
+			{
+				Name:        "list_directory",
+				Description: "Listar o conteúdo de um diretório do repositório (nome, tipo e tamanho de cada entrada)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Caminho do diretório (padrão: raiz)",
+						},
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA (padrão: branch padrão)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubContent{}),
+			},
+			{
+				Name:        "get_pr_diff",
+				Description: "Obter o diff unificado de um pull request",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"pr_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número do pull request",
+						},
+					},
+					"required": []string{"repo", "pr_number"},
+				},
+			},
+			{
+				Name:        "get_code_frequency",
+				Description: "Obter adições e remoções semanais de um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubCodeFrequency{}),
+			},
+			{
+				Name:        "get_assignees",
+				Description: "Listar usuários que podem ser atribuídos a issues de um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubUser{}),
+			},
+			{
+				Name:        "assign_issue",
+				Description: "Atribuir usuários a uma issue",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"issue_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue",
+						},
+						"assignees": arrayProp("Logins dos usuários a atribuir (veja get_assignees)", "string"),
+						"output":    outputProperty,
+					},
+					"required": []string{"repo", "issue_number", "assignees"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssue{}),
+			},
+			{
+				Name:        "get_followers",
+				Description: "Listar seguidores de um usuário GitHub",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"username": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do usuário (deixe vazio para usuário autenticado)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+				},
+				OutputSchema: outputSchemaOf([]GitHubUser{}),
+			},
+			{
+				Name:        "get_following",
+				Description: "Listar usuários que um usuário GitHub segue",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"username": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do usuário (deixe vazio para usuário autenticado)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+				},
+				OutputSchema: outputSchemaOf([]GitHubUser{}),
+			},
+			{
+				Name:        "repo_summary",
+				Description: "Resumo de um repositório: detalhes, linguagens, última release e issues abertas",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+					},
+					"required": []string{"repo"},
+				},
+			},
+			{
+				Name:        "list_repo_invitations",
+				Description: "Listar convites pendentes para colaborar em um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubInvitation{}),
+			},
+			{
+				Name:        "get_clone_urls",
+				Description: "Obter as URLs de clone (HTTPS, SSH e git) e a branch padrão de um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf(GitHubRepo{}),
+			},
+			{
+				Name: "grep_repo",
+				Description: "Procurar um texto nos arquivos de um repositório (busca de código do GitHub). " +
+					"Limitações: só a branch padrão é indexada, não aceita regex, ignora a maioria dos caracteres especiais e arquivos grandes",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "Texto a procurar (pode incluir qualificadores como language:go ou path:src)",
+						},
+						"limit": limitProperty,
+					},
+					"required": []string{"repo", "query"},
+				},
+			},
+			{
+				Name:        "search_repositories",
+				Description: "Buscar repositórios no GitHub pela sintaxe de busca (ex.: mcp language:go stars:>100)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "Termos da busca, com qualificadores opcionais (language:, user:, topic:, stars:...)",
+						},
+						"sort": map[string]interface{}{
+							"type":        "string",
+							"enum":        repoSearchSorts,
+							"description": "Ordenar por estrelas, forks ou última atualização (padrão: relevância)",
+						},
+						"order": map[string]interface{}{
+							"type":        "string",
+							"enum":        repoSearchOrders,
+							"description": "Direção da ordenação (padrão: desc; ignorado sem sort)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"query"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubRepo{}),
+			},
+			{
+				Name: "search_code",
+				Description: "Procurar código em todo o GitHub ou em um repositório (busca de código do GitHub), por exemplo para achar onde um símbolo é usado. " +
+					"Limitações: só a branch padrão é indexada e não aceita regex",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "Termos da busca (pode incluir qualificadores como language:go, path:src ou org:nome)",
+						},
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Restringir a um repositório: owner/repo ou URL do GitHub (vira o qualificador repo:)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"query"},
+				},
+				OutputSchema: outputSchemaOf(GitHubCodeSearch{}),
+			},
+			{
+				Name:        "changelog",
+				Description: "Gerar notas de release com os commits entre duas tags, agrupados por prefixo conventional commit (feat, fix, chore...) quando presente",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"from": map[string]interface{}{
+							"type":        "string",
+							"description": "Tag (ou ref) inicial, exclusiva",
+						},
+						"to": map[string]interface{}{
+							"type":        "string",
+							"description": "Tag (ou ref) final, inclusiva",
+						},
+						"include_merges": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Incluir commits de merge (padrão: false)",
+						},
+						"raw": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Devolver o texto dos usuários sem sanitização mesmo com SANITIZE_BODIES=true",
+						},
+					},
+					"required": []string{"repo", "from", "to"},
+				},
+			},
+			{
+				Name:        "watch_repo",
+				Description: "Acompanhar um repositório para receber suas notificações (ou silenciá-lo com ignored)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"ignored": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Silenciar todas as notificações do repositório (padrão: false)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf(GitHubSubscription{}),
+			},
+			{
+				Name:        "unwatch_repo",
+				Description: "Deixar de acompanhar um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf(GitHubSubscription{}),
+			},
+			{
+				Name:        "get_issue_events",
+				Description: "Listar os eventos de uma issue ou pull request (labeled, assigned, closed...)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"issue_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue ou pull request",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "issue_number"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubIssueEvent{}),
+			},
+			{
+				Name:        "get_branches",
+				Description: "Listar as branches de um repositório, com o SHA da ponta e se são protegidas",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubBranch{}),
+			},
+			{
+				Name:        "get_releases",
+				Description: "Listar as releases de um repositório, com tag, data e notas; com latest, só a última publicada",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"latest": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Trazer só a release publicada mais recente (ignora rascunhos e pre-releases)",
+						},
+						"raw": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Devolver o texto dos usuários sem sanitização mesmo com SANITIZE_BODIES=true",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubRelease{}),
+			},
+			{
+				Name:        "get_branches_ahead_behind",
+				Description: "Comparar branches com a branch padrão do repositório, informando quantos commits cada uma está à frente e atrás",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"branches": arrayProp("Branches a comparar com a branch padrão", "string"),
+					},
+					"required": []string{"repo", "branches"},
+				},
+			},
+			{
+				Name:        "list_run_artifacts",
+				Description: "Listar os artefatos de uma execução do GitHub Actions e, opcionalmente, baixar um deles",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"run_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID da execução do workflow",
+						},
+						"download": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Baixar o artefato indicado em name (padrão: false)",
+						},
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do artefato a baixar (obrigatório com download)",
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Arquivo de destino do zip, relativo a ARTIFACT_DIR (padrão: <name>.zip)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "run_id"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubArtifact{}),
+			},
+			{
+				Name:        "list_rulesets",
+				Description: "Listar os rulesets de um repositório (substitutos da proteção de branch clássica)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubRuleset{}),
+			},
+			{
+				Name:        "get_ruleset",
+				Description: "Obter um ruleset com suas condições (refs alvo) e regras",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"ruleset_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID do ruleset (veja list_rulesets)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "ruleset_id"},
+				},
+				OutputSchema: outputSchemaOf(GitHubRuleset{}),
+			},
+			{
+				Name:        "get_files",
+				Description: "Obter o conteúdo de vários arquivos de um repositório em uma única chamada",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"paths": arrayProp("Caminhos dos arquivos", "string"),
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA de todos os arquivos (padrão: branch padrão)",
+						},
+					},
+					"required": []string{"repo", "paths"},
+				},
+			},
+			{
+				Name:        "create_issue",
+				Description: "Abrir uma issue em um repositório",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "Título da issue",
+						},
+						"body": map[string]interface{}{
+							"type":        "string",
+							"description": "Corpo da issue em markdown",
+						},
+						"labels": arrayProp("Labels a aplicar (precisam existir no repositório)", "string"),
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "title"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssue{}),
+			},
+			{
+				Name:        "close_issue",
+				Description: "Fechar uma issue",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue",
+						},
+						"state_reason": map[string]interface{}{
+							"type":        "string",
+							"enum":        issueStateReasons["closed"],
+							"description": "Motivo do fechamento: completed (padrão do GitHub) ou not_planned",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "number"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssue{}),
+			},
+			{
+				Name:        "reopen_issue",
+				Description: "Reabrir uma issue fechada",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue",
+						},
+						"state_reason": map[string]interface{}{
+							"type":        "string",
+							"enum":        issueStateReasons["open"],
+							"description": "Motivo da reabertura: reopened",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "number"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssue{}),
+			},
+			{
+				Name:        "create_issue_from_template",
+				Description: "Criar uma issue a partir de um template de .github/ISSUE_TEMPLATE/, substituindo {{variáveis}} no título e no corpo",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"template": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do arquivo do template (ex.: bug_report ou bug_report.md)",
+						},
+						"variables": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "string"},
+							"description":          "Valores a substituir nos marcadores {{nome}} do template",
+						},
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "Título da issue (padrão: o title do template)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "template", "variables"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssue{}),
+			},
+			{
+				Name:        "star_history",
+				Description: "Mostrar a evolução das estrelas de um repositório por mês (total acumulado)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"max_pages": map[string]interface{}{
+							"type":        "integer",
+							"description": "Máximo de páginas de estrelas a ler (padrão: 10)",
+						},
+					},
+					"required": []string{"repo"},
+				},
+			},
+			{
+				Name:        "compare_commits",
+				Description: "Comparar duas refs (base...head): commits à frente/atrás e lista completa de arquivos alterados",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"base": map[string]interface{}{
+							"type":        "string",
+							"description": "Ref base (branch, tag ou SHA)",
+						},
+						"head": map[string]interface{}{
+							"type":        "string",
+							"description": "Ref comparada com base (branch, tag ou SHA)",
+						},
+						"max_pages": map[string]interface{}{
+							"type":        "integer",
+							"description": "Máximo de páginas da comparação a ler (padrão: 10)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "base", "head"},
+				},
+				OutputSchema: outputSchemaOf(GitHubComparison{}),
+			},
+			{
+				Name:        "set_repo_visibility",
+				Description: "Alterar a visibilidade de um repositório (public, private ou internal)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"visibility": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"public", "private", "internal"},
+							"description": "Nova visibilidade (internal só existe em organizações enterprise)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "visibility"},
+				},
+				OutputSchema: outputSchemaOf(GitHubRepo{}),
+			},
+			{
+				Name:        "list_environments",
+				Description: "Listar os ambientes de deploy de um repositório e suas regras de proteção",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubEnvironment{}),
+			},
+			{
+				Name:        "get_repo_activity",
+				Description: "Listar a atividade recente de um repositório: pushes, force pushes, merges e criação/remoção de branches",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"activity_type": map[string]interface{}{
+							"type":        "string",
+							"enum":        activityTypes,
+							"description": "Mostrar só um tipo de atividade",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubActivity{}),
+			},
+			{
+				Name:        "org_repos_summary",
+				Description: "Resumo dos repositórios de uma organização: total de repositórios e a primeira página, sem percorrer todas as páginas",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"org": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome da organização",
+						},
+						"limit": limitProperty,
+					},
+					"required": []string{"org"},
+				},
+			},
+			{
+				Name:        "get_file_at_commit",
+				Description: "Obter o conteúdo de um arquivo como estava em um commit específico",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Caminho do arquivo",
+						},
+						"sha": map[string]interface{}{
+							"type":        "string",
+							"description": "SHA do commit (7 a 40 caracteres hexadecimais)",
+						},
+					},
+					"required": []string{"repo", "path", "sha"},
+				},
+			},
+			{
+				Name:        "render_markdown",
+				Description: "Renderizar markdown como o GitHub faz, devolvendo o HTML resultante",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"text": map[string]interface{}{
+							"type":        "string",
+							"description": "Texto em markdown",
+						},
+						"mode": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"markdown", "gfm"},
+							"description": "markdown (padrão) ou gfm, que resolve menções e referências a issues",
+						},
+						"context": map[string]interface{}{
+							"type":        "string",
+							"description": "Repositório owner/repo usado para resolver referências como #123 no modo gfm",
+						},
+					},
+					"required": []string{"text"},
+				},
+			},
+			{
+				Name:        "get_zen",
+				Description: "Teste mínimo de conectividade e autenticação: devolve um aforismo aleatório do GitHub (não exige escopos)",
+				InputSchema: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+			{
+				Name:        "get_check_suites",
+				Description: "Listar as check suites (CI por app) de uma branch, tag ou commit",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "ref"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubCheckSuite{}),
+			},
+			{
+				Name:        "get_pages",
+				Description: "Obter a configuração do GitHub Pages de um repositório (estado, URL, origem e HTTPS)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf(GitHubPages{}),
+			},
+			{
+				Name:        "close_pull_request",
+				Description: "Fechar um pull request sem fazer merge (ou reabri-lo com state=open)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"pr_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número do pull request",
+						},
+						"state": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"closed", "open"},
+							"description": "Novo estado (padrão: closed)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "pr_number"},
+				},
+				OutputSchema: outputSchemaOf(GitHubPR{}),
+			},
+			{
+				Name:        "request_reviewers",
+				Description: "Pedir revisão de um pull request a usuários e/ou times",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"pr_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número do pull request",
+						},
+						"reviewers":      arrayProp("Logins dos usuários revisores", "string"),
+						"team_reviewers": arrayProp("Slugs dos times revisores (repositórios de organização)", "string"),
+						"output":         outputProperty,
+					},
+					"required": []string{"repo", "pr_number"},
+				},
+				OutputSchema: outputSchemaOf(GitHubReviewRequest{}),
+			},
+			{
+				Name:        "get_repo",
+				Description: "Obter detalhes de um repositório, incluindo o repositório original quando for um fork",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo"},
+				},
+				OutputSchema: outputSchemaOf(GitHubRepo{}),
+			},
+			{
+				Name:        "user_language_profile",
+				Description: "Somar as linguagens de todos os repositórios de um usuário em um ranking com bytes e percentuais",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"username": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do usuário",
+						},
+						"max_repos": map[string]interface{}{
+							"type":        "integer",
+							"description": "Máximo de repositórios analisados (padrão: 30)",
+						},
+						"include_forks": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Incluir forks na soma (padrão: false)",
+						},
+						"limit": limitProperty,
+					},
+					"required": []string{"username"},
+				},
+			},
+			{
+				Name:        "get_issue_comments",
+				Description: "Ler os comentários de uma issue ou pull request, com autor e data",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue ou do pull request",
+						},
+						"raw": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Devolver o texto dos usuários sem sanitização mesmo com SANITIZE_BODIES=true",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "number"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubIssueComment{}),
+			},
+			{
+				Name:        "create_issue_comment",
+				Description: "Comentar em uma issue ou pull request",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue ou do pull request",
+						},
+						"body": map[string]interface{}{
+							"type":        "string",
+							"description": "Texto do comentário (markdown)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "number", "body"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssueComment{}),
+			},
+			{
+				Name:        "update_issue_comment",
+				Description: "Editar o texto de um comentário de issue ou pull request",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"comment_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID do comentário",
+						},
+						"body": map[string]interface{}{
+							"type":        "string",
+							"description": "Novo texto do comentário (markdown)",
+						},
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "comment_id", "body"},
+				},
+				OutputSchema: outputSchemaOf(GitHubIssueComment{}),
+			},
+			{
+				Name:        "delete_issue_comment",
+				Description: "Remover um comentário de issue ou pull request",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"comment_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID do comentário",
+						},
+					},
+					"required": []string{"repo", "comment_id"},
+				},
+			},
+			{
+				Name:        "largest_files",
+				Description: "Listar os maiores arquivos de um repositório a partir da árvore recursiva, para diagnosticar repositórios inchados",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA (padrão: branch padrão)",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Quantidade de arquivos (padrão: 20)",
+						},
+					},
+					"required": []string{"repo"},
+				},
+			},
+			{
+				Name:        "get_pr_files",
+				Description: "Listar os arquivos alterados em um pull request com o patch de cada um, opcionalmente filtrados por glob",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"pr_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número do pull request",
+						},
+						"path_filter": map[string]interface{}{
+							"type":        "string",
+							"description": "Glob aplicado ao caminho ou ao nome do arquivo (ex.: *.go, internal/*/*.go)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"repo", "pr_number"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubFile{}),
+			},
+			{
+				Name:        "get_pull_request_files",
+				Description: "Listar os arquivos alterados em um pull request (mesmo que get_pr_files, com o número em number)",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório",
+						},
+						"number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número do pull request",
+						},
+						"path_filter": map[string]interface{}{
+							"type":        "string",
+							"description": "Glob aplicado ao caminho ou ao nome do arquivo (ex.: *.go, internal/*/*.go)",
+						},
+						"limit":  limitProperty,
+						"output": outputProperty,
+					},
+					"required": []string{"owner", "repo", "number"},
+				},
+				OutputSchema: outputSchemaOf([]GitHubFile{}),
+			},
+			{
+				Name:        "server_info",
+				Description: "Descrever este servidor: versão, protocolo MCP, host da API configurado, transportes e ferramentas registradas. Útil para diagnosticar implantações divergentes",
+				InputSchema: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+				OutputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":             map[string]interface{}{"type": "string"},
+						"version":          map[string]interface{}{"type": "string"},
+						"protocol_version": map[string]interface{}{"type": "string"},
+						"api_host":         map[string]interface{}{"type": "string"},
+						"transports":       arrayProp("Transportes habilitados", "string"),
+						"read_only":        map[string]interface{}{"type": "boolean"},
+						"tool_count":       map[string]interface{}{"type": "integer"},
+						"tools":            arrayProp("Nomes das ferramentas, como aparecem em tools/list", "string"),
+					},
+					"required": []string{"name", "version", "protocol_version", "api_host", "transports", "tool_count", "tools"},
+				},
+			},
+			{
+				Name:        "repo_work_items",
+				Description: "Visão de standup: issues abertas e pull requests abertos de um repositório, em seções separadas",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
 						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"limit": limitProperty,
+					},
+					"required": []string{"repo"},
+				},
+			},
+			{
+				Name:        "resolve_ref",
+				Description: "Resolver uma branch, tag ou SHA (abreviado ou completo) para o SHA completo do commit",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"ref": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch, tag ou SHA",
+						},
+					},
+					"required": []string{"repo", "ref"},
+				},
+			},
+			{
+				Name:        "diff_stats",
+				Description: "Resumo leve do tamanho de um commit ou comparação: linhas adicionadas/removidas e arquivos alterados, sem os patches",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"sha": map[string]interface{}{
+							"type":        "string",
+							"description": "Commit a resumir (use sha ou base+head)",
+						},
+						"base": map[string]interface{}{
+							"type":        "string",
+							"description": "Ref base da comparação",
+						},
+						"head": map[string]interface{}{
+							"type":        "string",
+							"description": "Ref head da comparação",
+						},
+					},
+					"required": []string{"repo"},
+				},
+			},
+			{
+				Name:        "add_reaction",
+				Description: "Adicionar uma reação (emoji) a uma issue ou pull request",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"owner": map[string]interface{}{
+							"type":        "string",
+							"description": "Proprietário do repositório (opcional se repo vier como owner/repo ou URL)",
+						},
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do repositório, owner/repo ou URL do GitHub",
+						},
+						"issue_number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número da issue ou pull request",
+						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"enum":        reactionContents,
+							"description": "Reação: +1, -1, laugh, heart, hooray, confused, rocket ou eyes",
+						},
+						"output": outputProperty,
 					},
+					"required": []string{"repo", "issue_number", "content"},
+				},
+				OutputSchema: outputSchemaOf(GitHubReaction{}),
+			},
+		},
+	}
+
+	// timeout_seconds é tratado em handleToolsCall e vale para todas as
+	// ferramentas.
+	for _, tool := range server.tools {
+		if properties, ok := tool.InputSchema["properties"].(map[string]interface{}); ok {
+			properties["timeout_seconds"] = timeoutProperty
+		}
+	}
+	return server
+}
+
+// HandleMessage processa uma mensagem JSON-RPC e devolve a resposta. Para
+// notificações (sem id, veja isNotification) o efeito é aplicado, mas a
+// resposta devolvida não deve ser enviada ao cliente; Serve já a descarta.
+func (s *MCPServer) HandleMessage(ctx context.Context, msg MCPMessage) MCPMessage {
+	switch msg.Method {
+	case "initialize":
+		return s.handleInitialize(msg)
+	case "notifications/initialized":
+		// O cliente concluiu o handshake. Nada muda no servidor, que já
+		// aceita chamadas desde o initialize; é uma notificação, então não
+		// há resposta a enviar.
+		return MCPMessage{}
+	case "tools/list":
+		if !s.initializeReceived {
+			return errorResult(msg.ID, -32002, "Server not initialized", "envie initialize antes de "+msg.Method)
+		}
+		return s.handleToolsList(msg)
+	case "tools/call":
+		if !s.initializeReceived {
+			return errorResult(msg.ID, -32002, "Server not initialized", "envie initialize antes de "+msg.Method)
+		}
+		start := time.Now()
+		response := s.handleToolsCall(ctx, msg)
+		s.auditToolCall(msg, response, time.Since(start))
+		return response
+	case "ping":
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  map[string]interface{}{"status": "pong"},
+		}
+	default:
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32601,
+				Message: "Method not found",
+			},
+		}
+	}
+}
+
+// auditEntry é uma linha do log de auditoria, em JSON.
+type auditEntry struct {
+	Time       string                 `json:"time"`
+	Client     string                 `json:"client,omitempty"`
+	Session    bool                   `json:"session_token"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments"`
+	Status     string                 `json:"status"`
+	ErrorCode  int                    `json:"error_code,omitempty"`
+	DurationMS int64                  `json:"duration_ms"`
+}
+
+// auditToolCall registra no log de auditoria a ferramenta chamada, os
+// argumentos com segredos mascarados, o resultado e a duração. Diferente de
+// GITHUB_TRACE, é feito para retenção e não para depuração.
+func (s *MCPServer) auditToolCall(msg MCPMessage, response MCPMessage, duration time.Duration) {
+	if s.audit == nil {
+		return
+	}
+
+	var params CallToolParams
+	if paramsBytes, err := json.Marshal(msg.Params); err == nil {
+		json.Unmarshal(paramsBytes, &params)
+	}
+
+	entry := auditEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Client:     s.clientName,
+		Session:    s.github != s.defaultClient,
+		Tool:       params.Name,
+		Arguments:  redactArguments(params.Arguments),
+		Status:     "success",
+		DurationMS: duration.Milliseconds(),
+	}
+	if response.Error != nil {
+		entry.Status = "error"
+		entry.ErrorCode = response.Error.Code
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Erro ao registrar auditoria de %s: %v", params.Name, err)
+		return
+	}
+	s.audit.Println(string(line))
+}
+
+var (
+	// secretKeyPattern reconhece nomes de argumentos que costumam carregar
+	// credenciais.
+	secretKeyPattern = regexp.MustCompile(`(?i)token|secret|passw|api[_-]?key|authorization|credential|private[_-]?key`)
+	// secretValuePattern reconhece valores com cara de credencial mesmo em
+	// argumentos de nome inocente (tokens do GitHub, Bearer, chaves PEM).
+	secretValuePattern = regexp.MustCompile(`(?i)\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b|bearer\s+\S+|-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+)
+
+const redacted = "[REDACTED]"
+
+// redactArguments devolve uma cópia de args com os segredos mascarados.
+func redactArguments(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if secretKeyPattern.MatchString(key) {
+			copied[key] = redacted
+			continue
+		}
+		copied[key] = redactValue(value)
+	}
+	return copied
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if secretValuePattern.MatchString(v) {
+			return redacted
+		}
+		return v
+	case map[string]interface{}:
+		return redactArguments(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = redactValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+func (s *MCPServer) handleInitialize(msg MCPMessage) MCPMessage {
+	var params InitializeParams
+	if msg.Params != nil {
+		paramsBytes, err := json.Marshal(msg.Params)
+		if err == nil {
+			err = json.Unmarshal(paramsBytes, &params)
+		}
+		if err != nil {
+			return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+
+	// Um novo initialize sempre redefine o cliente da sessão, para que o
+	// token de uma sessão anterior nunca seja reaproveitado.
+	s.clientName, _ = params.ClientInfo["name"].(string)
+	s.initializeReceived = true
+	couldWrite := s.canWrite()
+	if token := sessionToken(params); token != "" {
+		s.github = s.defaultClient.WithToken(token)
+	} else {
+		s.github = s.defaultClient
+	}
+	// O conjunto anunciado em tools/list mudou; Serve escreve a notificação
+	// logo após esta resposta.
+	if s.canWrite() != couldWrite {
+		s.Notify("notifications/tools/list_changed", nil)
+	}
+
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result: InitializeResult{
+			ProtocolVersion: protocolVersion,
+			Capabilities: map[string]interface{}{
+				"tools": map[string]interface{}{
+					"listChanged": true,
 				},
 			},
-			{
-				Name:        "get_repos",
-				Description: "Listar repositórios de um usuário",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"username": map[string]interface{}{
-							"type":        "string",
-							"description": "Nome do usuário (deixe vazio para usuário autenticado)",
-						},
-					},
-				},
+			ServerInfo: map[string]interface{}{
+				"name":    serverName,
+				"version": serverVersion,
+			},
+		},
+	}
+}
+
+// sessionToken lê o token opcional da sessão, enviado pelo cliente em
+// capabilities.experimental.githubToken.
+func sessionToken(params InitializeParams) string {
+	experimental, _ := params.Capabilities["experimental"].(map[string]interface{})
+	token, _ := experimental["githubToken"].(string)
+	return strings.TrimSpace(token)
+}
+
+// canWrite diz se a sessão tem um token com o qual o GitHub poderia aceitar
+// escritas. Sem token, tools/list deixa as writeTools de fora; elas passam a
+// ser anunciadas quando um initialize traz o token da sessão.
+func (s *MCPServer) canWrite() bool {
+	return s.github.token != ""
+}
+
+func (s *MCPServer) handleToolsList(msg MCPMessage) MCPMessage {
+	tools := make([]Tool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		if writeTools[tool.Name] && !s.canWrite() {
+			continue
+		}
+		tool.Name = s.toolPrefix + tool.Name
+		tools = append(tools, tool)
+	}
+
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result: map[string]interface{}{
+			"tools": tools,
+		},
+	}
+}
+
+func (s *MCPServer) handleToolsCall(ctx context.Context, msg MCPMessage) MCPMessage {
+	var params CallToolParams
+
+	// Converter params para JSON e depois fazer unmarshal
+	paramsBytes, err := json.Marshal(msg.Params)
+	if err != nil {
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    err.Error(),
+			},
+		}
+	}
+
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    err.Error(),
+			},
+		}
+	}
+
+	// Com MCP_TOOL_PREFIX os clientes só conhecem os nomes prefixados; nomes
+	// sem o prefixo não são roteados.
+	if s.toolPrefix != "" {
+		name := strings.TrimPrefix(params.Name, s.toolPrefix)
+		if name == params.Name {
+			name = ""
+		}
+		params.Name = name
+	}
+
+	if s.readOnly && writeTools[params.Name] {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("%s altera o repositório e está desabilitada no modo somente leitura (READ_ONLY=true)", params.Name))
+	}
+
+	if tool, ok := s.findTool(params.Name); ok {
+		if err := validateArguments(tool.InputSchema, params.Arguments); err != nil {
+			return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+		}
+		properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+		if _, ok := properties["output"]; !ok && jsonOutput(params.Arguments) {
+			return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("%s não suporta output json", params.Name))
+		}
+		// O JSON traz o texto dos usuários como veio da API, sem passar por
+		// sanitizeBody; com SANITIZE_BODIES isso precisa ser pedido.
+		if s.sanitize && jsonOutput(params.Arguments) && !boolArg(params.Arguments, "raw") {
+			return errorResult(msg.ID, -32602, "Invalid params", "output json devolve o texto original da API; com SANITIZE_BODIES=true, passe também raw: true")
+		}
+	}
+	if _, present := params.Arguments["limit"]; present {
+		if limit, ok := intArg(params.Arguments, "limit"); !ok || limit < 1 {
+			return errorResult(msg.ID, -32602, "Invalid params", "limit deve ser um inteiro positivo")
+		}
+	}
+	if _, present := params.Arguments["timeout_seconds"]; present {
+		seconds, ok := intArg(params.Arguments, "timeout_seconds")
+		if !ok || seconds < 1 || seconds > maxToolTimeoutSeconds {
+			return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("timeout_seconds deve ser um inteiro entre 1 e %d", maxToolTimeoutSeconds))
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+		defer cancel()
+	}
+
+	switch params.Name {
+	case "get_user":
+		return s.handleGetUser(ctx, msg, params)
+	case "whoami":
+		return s.handleWhoami(ctx, msg)
+	case "get_repos":
+		return s.handleGetRepos(ctx, msg, params)
+	case "get_repos_multi":
+		return s.handleGetReposMulti(ctx, msg, params)
+	case "get_issues":
+		return s.handleGetIssues(ctx, msg, params)
+	case "get_pull_requests":
+		return s.handleGetPullRequests(ctx, msg, params)
+	case "get_commits":
+		return s.handleGetCommits(ctx, msg, params)
+	case "get_content":
+		return s.handleGetContent(ctx, msg, params)
+	case "list_directory":
+		return s.handleListDirectory(ctx, msg, params)
+	case "get_pr_diff":
+		return s.handleGetPullRequestDiff(ctx, msg, params)
+	case "get_code_frequency":
+		return s.handleGetCodeFrequency(ctx, msg, params)
+	case "get_assignees":
+		return s.handleGetAssignees(ctx, msg, params)
+	case "assign_issue":
+		return s.handleAssignIssue(ctx, msg, params)
+	case "get_followers":
+		return s.handleGetFollowers(ctx, msg, params)
+	case "get_following":
+		return s.handleGetFollowing(ctx, msg, params)
+	case "repo_summary":
+		return s.handleRepoSummary(ctx, msg, params)
+	case "list_repo_invitations":
+		return s.handleListRepoInvitations(ctx, msg, params)
+	case "get_clone_urls":
+		return s.handleGetCloneURLs(ctx, msg, params)
+	case "search_repositories":
+		return s.handleSearchRepositories(ctx, msg, params)
+	case "search_code":
+		return s.handleSearchCode(ctx, msg, params)
+	case "grep_repo":
+		return s.handleGrepRepo(ctx, msg, params)
+	case "changelog":
+		return s.handleChangelog(ctx, msg, params)
+	case "watch_repo":
+		return s.handleWatchRepo(ctx, msg, params)
+	case "unwatch_repo":
+		return s.handleUnwatchRepo(ctx, msg, params)
+	case "add_reaction":
+		return s.handleAddReaction(ctx, msg, params)
+	case "get_issue_events":
+		return s.handleGetIssueEvents(ctx, msg, params)
+	case "get_branches_ahead_behind":
+		return s.handleBranchesAheadBehind(ctx, msg, params)
+	case "get_branches":
+		return s.handleGetBranches(ctx, msg, params)
+	case "get_releases":
+		return s.handleGetReleases(ctx, msg, params)
+	case "list_run_artifacts":
+		return s.handleListRunArtifacts(ctx, msg, params)
+	case "get_files":
+		return s.handleGetFiles(ctx, msg, params)
+	case "create_issue":
+		return s.handleCreateIssue(ctx, msg, params)
+	case "close_issue":
+		return s.handleUpdateIssueState(ctx, msg, params, "closed")
+	case "reopen_issue":
+		return s.handleUpdateIssueState(ctx, msg, params, "open")
+	case "create_issue_from_template":
+		return s.handleCreateIssueFromTemplate(ctx, msg, params)
+	case "star_history":
+		return s.handleStarHistory(ctx, msg, params)
+	case "compare_commits":
+		return s.handleCompareCommits(ctx, msg, params)
+	case "set_repo_visibility":
+		return s.handleSetRepoVisibility(ctx, msg, params)
+	case "list_environments":
+		return s.handleListEnvironments(ctx, msg, params)
+	case "get_repo_activity":
+		return s.handleGetRepoActivity(ctx, msg, params)
+	case "org_repos_summary":
+		return s.handleOrgReposSummary(ctx, msg, params)
+	case "get_file_at_commit":
+		return s.handleGetFileAtCommit(ctx, msg, params)
+	case "render_markdown":
+		return s.handleRenderMarkdown(ctx, msg, params)
+	case "get_zen":
+		return s.handleGetZen(ctx, msg)
+	case "get_check_suites":
+		return s.handleGetCheckSuites(ctx, msg, params)
+	case "get_pages":
+		return s.handleGetPages(ctx, msg, params)
+	case "close_pull_request":
+		return s.handleClosePullRequest(ctx, msg, params)
+	case "request_reviewers":
+		return s.handleRequestReviewers(ctx, msg, params)
+	case "get_repo":
+		return s.handleGetRepo(ctx, msg, params)
+	case "user_language_profile":
+		return s.handleUserLanguageProfile(ctx, msg, params)
+	case "get_issue_comments":
+		return s.handleGetIssueComments(ctx, msg, params)
+	case "create_issue_comment":
+		return s.handleCreateIssueComment(ctx, msg, params)
+	case "update_issue_comment":
+		return s.handleUpdateIssueComment(ctx, msg, params)
+	case "delete_issue_comment":
+		return s.handleDeleteIssueComment(ctx, msg, params)
+	case "largest_files":
+		return s.handleLargestFiles(ctx, msg, params)
+	case "get_pr_files", "get_pull_request_files":
+		return s.handleGetPRFiles(ctx, msg, params)
+	case "server_info":
+		return s.handleServerInfo(msg)
+	case "repo_work_items":
+		return s.handleRepoWorkItems(ctx, msg, params)
+	case "resolve_ref":
+		return s.handleResolveRef(ctx, msg, params)
+	case "diff_stats":
+		return s.handleDiffStats(ctx, msg, params)
+	case "list_rulesets":
+		return s.handleListRulesets(ctx, msg, params)
+	case "get_ruleset":
+		return s.handleGetRuleset(ctx, msg, params)
+	default:
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32601,
+				Message: "Tool not found",
+			},
+		}
+	}
+}
+
+func (s *MCPServer) handleGetUser(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	username, _ := params.Arguments["username"].(string)
+
+	user, err := s.github.GetUser(ctx, username)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails("", []field{
+		{"Usuário", user.Login},
+		{"Nome", user.Name},
+		{"Bio", user.Bio},
+		{"Localização", user.Location},
+		{"Empresa", user.Company},
+		{"Seguidores", fmt.Sprint(user.Followers)},
+		{"Seguindo", fmt.Sprint(user.Following)},
+		{"URL", user.HTMLURL},
+	}), user)
+}
+
+func (s *MCPServer) handleWhoami(ctx context.Context, msg MCPMessage) MCPMessage {
+	info, err := s.github.GetTokenInfo(ctx)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	tokenType := "clássico"
+	scopes := strings.Join(info.Scopes, ", ")
+	if info.FineGrained {
+		tokenType = "fine-grained"
+		scopes = "n/a (permissões definidas por repositório)"
+	} else if scopes == "" {
+		scopes = "nenhum"
+	}
+
+	reset := "desconhecido"
+	if !info.RateLimitReset.IsZero() {
+		reset = info.RateLimitReset.Format("15:04:05")
+	}
+
+	text := s.renderDetails("", []field{
+		{"Usuário", info.User.Login},
+		{"Nome", info.User.Name},
+		{"Tipo de token", tokenType},
+		{"Escopos", scopes},
+		{"Requisições restantes", fmt.Sprintf("%d de %d (renova às %s)", info.RateLimitRemaining, info.RateLimitLimit, reset)},
+		{"URL", info.User.HTMLURL},
+	})
+
+	structured := map[string]interface{}{
+		"login":                info.User.Login,
+		"name":                 info.User.Name,
+		"token_type":           "classic",
+		"scopes":               append([]string{}, info.Scopes...),
+		"rate_limit_limit":     info.RateLimitLimit,
+		"rate_limit_remaining": info.RateLimitRemaining,
+		"rate_limit_reset":     "",
+		"html_url":             info.User.HTMLURL,
+	}
+	if info.FineGrained {
+		structured["token_type"] = "fine-grained"
+	}
+	if !info.RateLimitReset.IsZero() {
+		structured["rate_limit_reset"] = info.RateLimitReset.Format(time.RFC3339)
+	}
+
+	return structuredResult(msg.ID, text, structured)
+}
+
+func (s *MCPServer) handleGetRepos(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	username, _ := params.Arguments["username"].(string)
+
+	opts, err := pageOptionsArg(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	repos, err := s.github.GetRepos(ctx, username, opts)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	text := s.renderList(fmt.Sprintf("Repositórios (%d)", len(repos)), limitItems(repoItems(repos), params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, repos[:limitCount(len(repos), params.Arguments)])
+}
+
+func repoItems(repos []GitHubRepo) []listItem {
+	items := make([]listItem, 0, len(repos))
+	for _, repo := range repos {
+		items = append(items, listItem{
+			Title: repo.Name,
+			URL:   repo.HTMLURL,
+			Fields: []field{
+				{"Descrição", repo.Description},
+				{"Privado", fmt.Sprint(repo.Private)},
+			},
+		})
+	}
+	return items
+}
+
+// maxConcurrentRequests limita quantas requisições uma ferramenta em lote
+// dispara ao mesmo tempo contra a API do GitHub.
+const maxConcurrentRequests = 4
+
+func (s *MCPServer) handleGetReposMulti(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	usernames := stringSliceArg(params.Arguments, "usernames")
+	if len(usernames) == 0 {
+		return errorResult(msg.ID, -32602, "Invalid params", "usernames é obrigatório")
+	}
+
+	type userRepos struct {
+		repos []GitHubRepo
+		err   error
+	}
+	results := make([]userRepos, len(usernames))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRequests)
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			repos, err := s.github.GetRepos(ctx, username, PageOptions{})
+			results[i] = userRepos{repos: repos, err: err}
+		}(i, username)
+	}
+	wg.Wait()
+
+	var result strings.Builder
+	for i, username := range usernames {
+		if results[i].err != nil {
+			result.WriteString(s.renderDetails(fmt.Sprintf("Repositórios de %s", username), []field{
+				{"Erro", results[i].err.Error()},
+			}))
+			result.WriteString("\n")
+			continue
+		}
+		repos := results[i].repos
+		result.WriteString(s.renderList(fmt.Sprintf("Repositórios de %s (%d)", username, len(repos)), limitItems(repoItems(repos), params.Arguments)))
+		result.WriteString("\n")
+	}
+
+	return textResult(msg.ID, result.String())
+}
+
+func (s *MCPServer) handleGetBranches(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	branches, err := s.github.GetBranches(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	shown := branches[:limitCount(len(branches), params.Arguments)]
+	items := make([]listItem, 0, len(shown))
+	for _, branch := range shown {
+		protected := "não"
+		if branch.Protected {
+			protected = "sim"
+		}
+		items = append(items, listItem{Title: branch.Name, Fields: []field{
+			{"SHA", shortSHA(branch.Commit.SHA)},
+			{"Protegida", protected},
+		}})
+	}
+
+	text := s.renderList(fmt.Sprintf("Branches de %s/%s (%d)", owner, repo, len(branches)), items)
+	return s.outputResult(msg.ID, params.Arguments, text, shown)
+}
+
+func (s *MCPServer) handleGetReleases(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	latest := boolArg(params.Arguments, "latest")
+	header := fmt.Sprintf("Releases de %s/%s", owner, repo)
+
+	var releases []GitHubRelease
+	if latest {
+		// /releases/latest ignora rascunhos e pre-releases e responde 404
+		// quando não há release publicada.
+		header = fmt.Sprintf("Última release de %s/%s", owner, repo)
+		release, err := s.github.GetLatestRelease(ctx, owner, repo)
+		if err != nil {
+			return githubErrorResult(msg.ID, err)
+		}
+		if release == nil {
+			text := s.renderDetails(header, []field{{"Estado", "nenhuma release publicada"}})
+			return s.outputResult(msg.ID, params.Arguments, text, []GitHubRelease{})
+		}
+		releases = []GitHubRelease{*release}
+	} else {
+		releases, err = s.github.GetReleases(ctx, owner, repo)
+		if err != nil {
+			return githubErrorResult(msg.ID, err)
+		}
+		header = fmt.Sprintf("%s (%d)", header, len(releases))
+	}
+
+	shown := releases[:limitCount(len(releases), params.Arguments)]
+
+	var result strings.Builder
+	for _, release := range shown {
+		fields := []field{
+			{"Nome", s.userText(release.Name, params.Arguments)},
+			{"Publicada", release.PublishedAt},
+		}
+		switch {
+		case release.Draft:
+			fields = append(fields, field{"Tipo", "rascunho"})
+		case release.Prerelease:
+			fields = append(fields, field{"Tipo", "pre-release"})
+		}
+		fields = append(fields, field{"URL", release.HTMLURL})
+
+		result.WriteString("\n")
+		result.WriteString(s.renderDetails(release.TagName, fields))
+		if release.Body != "" {
+			result.WriteString(s.renderBlock("Notas", s.userText(release.Body, params.Arguments)))
+		}
+		result.WriteString("\n")
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, header+":\n"+result.String(), shown)
+}
+
+func (s *MCPServer) handleBranchesAheadBehind(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	branches := stringSliceArg(params.Arguments, "branches")
+	if len(branches) == 0 {
+		return errorResult(msg.ID, -32602, "Invalid params", "branches deve ser uma lista não vazia")
+	}
+
+	base, err := s.github.DefaultBranch(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	type branchComparison struct {
+		comparison *GitHubComparison
+		err        error
+	}
+	results := make([]branchComparison, len(branches))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRequests)
+	for i, branch := range branches {
+		wg.Add(1)
+		go func(i int, branch string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			comparison, err := s.github.CompareCommits(ctx, owner, repo, base, branch)
+			results[i] = branchComparison{comparison: comparison, err: err}
+		}(i, branch)
+	}
+	wg.Wait()
+
+	items := make([]listItem, 0, len(branches))
+	for i, branch := range branches {
+		if results[i].err != nil {
+			items = append(items, listItem{Title: branch, Fields: []field{{"Erro", results[i].err.Error()}}})
+			continue
+		}
+		comparison := results[i].comparison
+		items = append(items, listItem{
+			Title: branch,
+			URL:   comparison.HTMLURL,
+			Fields: []field{
+				{"À frente", strconv.Itoa(comparison.AheadBy)},
+				{"Atrás", strconv.Itoa(comparison.BehindBy)},
+				{"Estado", comparison.Status},
+			},
+		})
+	}
+
+	header := fmt.Sprintf("Branches de %s/%s comparadas com %s (%d)", owner, repo, base, len(branches))
+	return textResult(msg.ID, s.renderList(header, items))
+}
+
+func (s *MCPServer) handleGetIssues(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	filter := IssueFilter{Milestone: scalarArg(params.Arguments, "milestone")}
+	if err := validateMilestone(filter.Milestone); err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	since, _ := params.Arguments["since"].(string)
+	if filter.Since, err = parseSince(strings.TrimSpace(since)); err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	filter.State = "open"
+	if state, _ := params.Arguments["state"].(string); strings.TrimSpace(state) != "" {
+		filter.State = strings.TrimSpace(state)
+	}
+	if err := validateIssueState(filter.State); err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	labels, _ := params.Arguments["labels"].(string)
+	filter.Labels = normalizeLabels(labels)
+	filter.IncludePullRequests = boolArg(params.Arguments, "include_prs")
+
+	opts, err := pageOptionsArg(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	issues, err := s.github.GetIssues(ctx, owner, repo, filter, opts)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(issues))
+	for _, issue := range issues {
+		items = append(items, listItem{
+			Title:  fmt.Sprintf("#%d: %s", issue.Number, s.userText(issue.Title, params.Arguments)),
+			URL:    issue.HTMLURL,
+			Fields: []field{{"Estado", issue.State}},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Issues do %s/%s (%d)", owner, repo, len(issues)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, issues[:limitCount(len(issues), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetPullRequests(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	opts, err := pageOptionsArg(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	prs, err := s.github.GetPullRequests(ctx, owner, repo, opts)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(prs))
+	for _, pr := range prs {
+		items = append(items, listItem{
+			Title:  fmt.Sprintf("#%d: %s", pr.Number, s.userText(pr.Title, params.Arguments)),
+			URL:    pr.HTMLURL,
+			Fields: []field{{"Estado", pr.State}},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Pull Requests do %s/%s (%d)", owner, repo, len(prs)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, prs[:limitCount(len(prs), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetCommits(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	opts, err := pageOptionsArg(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	ref := scalarArg(params.Arguments, "ref")
+	commits, err := s.github.GetCommits(ctx, owner, repo, ref, opts)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	verifiedOnly := boolArg(params.Arguments, "verified_only")
+	showVerification := verifiedOnly || boolArg(params.Arguments, "show_verification")
+
+	shown := make([]GitHubCommit, 0, len(commits))
+	items := make([]listItem, 0, len(commits))
+	for _, commit := range commits {
+		if verifiedOnly && (commit.Verification == nil || !commit.Verification.Verified) {
+			continue
+		}
+		shown = append(shown, commit)
+		fields := []field{
+			{"Mensagem", s.userText(commit.Message, params.Arguments)},
+			{"Autor", fmt.Sprintf("%s (%s)", commit.Author.Name, commit.Author.Email)},
+			{"Data", commit.Author.Date},
+		}
+		if showVerification {
+			fields = append(fields, field{"Assinatura", verificationStatus(commit.Verification)})
+		}
+		items = append(items, listItem{
+			Title:  commit.SHA[:7],
+			URL:    commit.HTMLURL,
+			Fields: fields,
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Commits do %s/%s (%d)", owner, repo, len(items)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, shown[:limitCount(len(shown), params.Arguments)])
+}
+
+// verificationStatus resume a verificação da assinatura de um commit; o
+// motivo é o código devolvido pelo GitHub (ex.: unsigned, expired_key).
+func verificationStatus(v *GitHubVerification) string {
+	switch {
+	case v == nil:
+		return "✘ não verificada (sem dados de verificação)"
+	case v.Verified:
+		return "✔ verificada"
+	default:
+		return fmt.Sprintf("✘ não verificada (%s)", v.Reason)
+	}
+}
+
+func (s *MCPServer) handleGetContent(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	path, _ := params.Arguments["path"].(string)
+	ref := scalarArg(params.Arguments, "ref")
+
+	content, err := s.github.GetContentAtRef(ctx, owner, repo, path, ref)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	var result strings.Builder
+	result.WriteString(s.renderDetails(fmt.Sprintf("Conteúdo de %s/%s/%s", owner, repo, path), []field{
+		{"Tipo", content.Type},
+		{"Tamanho", fmt.Sprintf("%d bytes", content.Size)},
+		{"URL", content.HTMLURL},
+	}))
+
+	// No JSON, arquivos de texto vêm decodificados; binários ficam em base64,
+	// como a API os devolve.
+	data := *content
+	if content.Content != "" {
+		text, err := decodeContent(content)
+		if err != nil {
+			return githubErrorResult(msg.ID, err)
+		}
+		if isBinary(text) {
+			result.WriteString("\n[arquivo binário: conteúdo omitido]\n")
+			return s.outputResult(msg.ID, params.Arguments, result.String(), data)
+		}
+		data.Content, data.Encoding = text, ""
+
+		text, truncated := truncateText(text, maxContentSize)
+		result.WriteString(s.renderBlock("Conteúdo", text))
+		if truncated {
+			result.WriteString(fmt.Sprintf("\n[conteúdo truncado em %d bytes]\n", maxContentSize))
+		}
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, result.String(), data)
+}
+
+// handleListDirectory lista as entradas de um diretório do repositório.
+func (s *MCPServer) handleListDirectory(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	path := strings.Trim(scalarArg(params.Arguments, "path"), "/")
+	ref := scalarArg(params.Arguments, "ref")
+
+	entries, err := s.github.GetDirectory(ctx, owner, repo, path, ref)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	shown := entries[:limitCount(len(entries), params.Arguments)]
+	items := make([]listItem, 0, len(shown))
+	for _, entry := range shown {
+		fields := []field{{"Tipo", entry.Type}}
+		if entry.Type == "file" {
+			fields = append(fields, field{"Tamanho", fmt.Sprintf("%d bytes", entry.Size)})
+		}
+		items = append(items, listItem{Title: entry.Name, Fields: fields})
+	}
+
+	location := owner + "/" + repo
+	if path != "" {
+		location += "/" + path
+	}
+	header := fmt.Sprintf("Conteúdo de %s (%d)", location, len(entries))
+	return s.outputResult(msg.ID, params.Arguments, s.renderList(header, items), shown)
+}
+
+// handleGetFiles busca os arquivos em paralelo e devolve um bloco de conteúdo
+// por caminho. Erros de um arquivo aparecem no bloco dele; o total de texto
+// devolvido respeita maxContentSize.
+func (s *MCPServer) handleGetFiles(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	paths := stringSliceArg(params.Arguments, "paths")
+	if len(paths) == 0 {
+		return errorResult(msg.ID, -32602, "Invalid params", "paths deve ser uma lista não vazia")
+	}
+	ref := scalarArg(params.Arguments, "ref")
+
+	type fileResult struct {
+		text   string
+		err    error
+		binary bool
+	}
+	results := make([]fileResult, len(paths))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRequests)
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			content, err := s.github.GetContentAtRef(ctx, owner, repo, path, ref)
+			if err != nil {
+				results[i] = fileResult{err: err}
+				return
+			}
+			if content.Type != "file" {
+				results[i] = fileResult{err: fmt.Errorf("%s não é um arquivo (tipo %s)", path, content.Type)}
+				return
+			}
+			text, err := decodeContent(content)
+			results[i] = fileResult{text: text, err: err, binary: err == nil && isBinary(text)}
+		}(i, path)
+	}
+	wg.Wait()
+
+	blocks := make([]map[string]interface{}, 0, len(paths))
+	remaining := maxContentSize
+	for i, path := range paths {
+		header := fmt.Sprintf("%s/%s/%s", owner, repo, path)
+		var body string
+		switch {
+		case results[i].err != nil:
+			body = s.renderDetails(header, []field{{"Erro", results[i].err.Error()}})
+		case results[i].binary:
+			body = s.renderDetails(header, []field{{"Tamanho", fmt.Sprintf("%d bytes", len(results[i].text))}})
+			body += "\n[arquivo binário: conteúdo omitido]\n"
+		case remaining == 0:
+			body = s.renderDetails(header, []field{{"Omitido", fmt.Sprintf("limite de %d bytes da resposta atingido", maxContentSize)}})
+		default:
+			text, truncated := truncateText(results[i].text, remaining)
+			remaining -= len(text)
+			body = s.renderDetails(header, []field{{"Tamanho", fmt.Sprintf("%d bytes", len(results[i].text))}})
+			body += s.renderBlock("Conteúdo", text)
+			if truncated {
+				body += fmt.Sprintf("\n[conteúdo truncado: limite de %d bytes da resposta atingido]\n", maxContentSize)
+			}
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": body})
+	}
+
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result:  CallToolResult{Content: blocks},
+	}
+}
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+func (s *MCPServer) handleGetFileAtCommit(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	path, _ := params.Arguments["path"].(string)
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "path é obrigatório")
+	}
+	sha, _ := params.Arguments["sha"].(string)
+	sha = strings.TrimSpace(sha)
+	if !commitSHAPattern.MatchString(sha) {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("sha inválido: %q (use de 7 a 40 caracteres hexadecimais)", sha))
+	}
+
+	content, err := s.github.GetContentAtRef(ctx, owner, repo, path, sha)
+	if err == nil && content.Type != "file" {
+		err = fmt.Errorf("%s não é um arquivo (tipo %s)", path, content.Type)
+	}
+	var text string
+	if err == nil {
+		text, err = decodeContent(content)
+	}
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	var result strings.Builder
+	result.WriteString(s.renderDetails(fmt.Sprintf("%s/%s/%s em %s", owner, repo, path, shortSHA(sha)), []field{
+		{"Tamanho", fmt.Sprintf("%d bytes", content.Size)},
+		{"URL", content.HTMLURL},
+	}))
+	if isBinary(text) {
+		result.WriteString("\n[arquivo binário: conteúdo omitido]\n")
+		return textResult(msg.ID, result.String())
+	}
+
+	text, truncated := truncateText(text, maxContentSize)
+	result.WriteString(s.renderBlock("Conteúdo", text))
+	if truncated {
+		result.WriteString(fmt.Sprintf("\n[conteúdo truncado em %d bytes]\n", maxContentSize))
+	}
+	return textResult(msg.ID, result.String())
+}
+
+func (s *MCPServer) handleGetPullRequestDiff(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "pr_number")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "pr_number deve ser um número inteiro")
+	}
+
+	diff, err := s.github.GetPullRequestDiff(ctx, owner, repo, number)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	diff, truncated := truncateText(diff, maxContentSize)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Diff do PR #%d de %s/%s:\n", number, owner, repo))
+	result.WriteString(s.renderBlock("Diff", diff))
+	if truncated {
+		result.WriteString(fmt.Sprintf("\n[diff truncado em %d bytes]\n", maxContentSize))
+	}
+
+	return textResult(msg.ID, result.String())
+}
+
+func (s *MCPServer) handleGetCodeFrequency(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	frequency, err := s.github.GetCodeFrequency(ctx, owner, repo)
+	if errors.Is(err, errStatsNotReady) {
+		return s.outputResult(msg.ID, params.Arguments, fmt.Sprintf("Frequência de código de %s/%s: %v", owner, repo, err), []GitHubCodeFrequency{})
+	}
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(frequency))
+	for _, week := range frequency {
+		items = append(items, listItem{
+			Title: time.Unix(week.Week, 0).UTC().Format("2006-01-02"),
+			Fields: []field{
+				{"Adições", fmt.Sprint(week.Additions)},
+				{"Remoções", fmt.Sprint(week.Deletions)},
+			},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Frequência de código de %s/%s (%d semanas)", owner, repo, len(frequency)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, frequency[:limitCount(len(frequency), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetAssignees(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	assignees, err := s.github.GetAssignees(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	text := s.renderList(fmt.Sprintf("Usuários atribuíveis em %s/%s (%d)", owner, repo, len(assignees)), limitItems(userItems(assignees), params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, assignees[:limitCount(len(assignees), params.Arguments)])
+}
+
+func (s *MCPServer) handleAssignIssue(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "issue_number")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "issue_number deve ser um número inteiro")
+	}
+	assignees := stringSliceArg(params.Arguments, "assignees")
+	if len(assignees) == 0 {
+		return errorResult(msg.ID, -32602, "Invalid params", "assignees deve ser uma lista não vazia de logins")
+	}
+
+	issue, err := s.github.AddAssignees(ctx, owner, repo, number, assignees)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	header := fmt.Sprintf("Responsáveis pela issue #%d de %s/%s (%d)", issue.Number, owner, repo, len(issue.Assignees))
+	return s.outputResult(msg.ID, params.Arguments, s.renderList(header, userItems(issue.Assignees)), issue)
+}
+
+func (s *MCPServer) handleAddReaction(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "issue_number")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "issue_number deve ser um número inteiro")
+	}
+	content, _ := params.Arguments["content"].(string)
+	content = strings.TrimSpace(content)
+	if !validReaction(content) {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("content inválido: %q (use %s)", content, strings.Join(reactionContents, ", ")))
+	}
+
+	reaction, err := s.github.AddReaction(ctx, owner, repo, number, content)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	header := fmt.Sprintf("Reação em %s/%s#%d", owner, repo, number)
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(header, []field{
+		{"ID", strconv.FormatInt(reaction.ID, 10)},
+		{"Reação", reaction.Content},
+		{"Usuário", reaction.User.Login},
+	}), reaction)
+}
+
+func (s *MCPServer) handleGetIssueEvents(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "issue_number")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "issue_number deve ser um número inteiro")
+	}
+
+	events, err := s.github.GetIssueEvents(ctx, owner, repo, number)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(events))
+	for _, event := range events {
+		actor := "(usuário removido)"
+		if event.Actor != nil {
+			actor = event.Actor.Login
+		}
+		fields := []field{{"Autor", actor}, {"Data", event.CreatedAt}}
+		if event.Label != nil {
+			fields = append(fields, field{"Label", event.Label.Name})
+		}
+		if event.Assignee != nil {
+			fields = append(fields, field{"Responsável", event.Assignee.Login})
+		}
+		items = append(items, listItem{Title: event.Event, Fields: fields})
+	}
+
+	header := fmt.Sprintf("Eventos de %s/%s#%d (%d)", owner, repo, number, len(events))
+	return s.outputResult(msg.ID, params.Arguments, s.renderList(header, limitItems(items, params.Arguments)), events[:limitCount(len(events), params.Arguments)])
+}
+
+func (s *MCPServer) handleListRunArtifacts(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	runID, ok := intArg(params.Arguments, "run_id")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "run_id deve ser um número inteiro")
+	}
+	download := boolArg(params.Arguments, "download")
+	name, _ := params.Arguments["name"].(string)
+	name = strings.TrimSpace(name)
+	if download && name == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "name é obrigatório com download")
+	}
+	// Só o download grava algo (no disco local); a listagem continua
+	// disponível no modo somente leitura.
+	if download && s.readOnly {
+		return errorResult(msg.ID, -32602, "Invalid params", "download de artefatos está desabilitado no modo somente leitura (READ_ONLY=true)")
+	}
+	if download && s.artifactDir == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "downloads desativados: defina ARTIFACT_DIR")
+	}
+
+	artifacts, err := s.github.ListRunArtifacts(ctx, owner, repo, int64(runID))
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		fields := []field{{"Tamanho", fmt.Sprintf("%d bytes", artifact.SizeInBytes)}}
+		if artifact.Expired {
+			fields = append(fields, field{"Expirado", "sim"})
+		} else {
+			fields = append(fields, field{"Download", artifact.ArchiveDownloadURL})
+		}
+		items = append(items, listItem{Title: artifact.Name, Fields: fields})
+	}
+	text := s.renderList(fmt.Sprintf("Artefatos da execução %d de %s/%s (%d)", runID, owner, repo, len(artifacts)), limitItems(items, params.Arguments))
+	shown := artifacts[:limitCount(len(artifacts), params.Arguments)]
+
+	if !download {
+		return s.outputResult(msg.ID, params.Arguments, text, shown)
+	}
+
+	var artifact *GitHubArtifact
+	for i := range artifacts {
+		if artifacts[i].Name == name {
+			artifact = &artifacts[i]
+			break
+		}
+	}
+	if artifact == nil {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("artefato %q não encontrado na execução %d", name, runID))
+	}
+	if artifact.Expired {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("artefato %q expirou em %s", name, artifact.ExpiresAt))
+	}
+
+	path, _ := params.Arguments["path"].(string)
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = name + ".zip"
+	}
+	target, err := s.artifactPath(path)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	written, err := s.downloadArtifactTo(ctx, owner, repo, artifact.ID, target)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	text += "\n" + s.renderDetails("Download concluído", []field{
+		{"Artefato", name},
+		{"Arquivo", target},
+		{"Tamanho", fmt.Sprintf("%d bytes", written)},
+	})
+	return s.outputResult(msg.ID, params.Arguments, text, shown)
+}
+
+// artifactPath resolve path dentro de artifactDir. O caminho vem do modelo,
+// então caminhos absolutos e qualquer saída do diretório (..) são recusados.
+func (s *MCPServer) artifactPath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("path deve ser relativo a ARTIFACT_DIR: %q", path)
+	}
+	target := filepath.Join(s.artifactDir, filepath.Clean(path))
+	if !strings.HasPrefix(target, s.artifactDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("path sai de ARTIFACT_DIR: %q", path)
+	}
+	return target, nil
+}
+
+// downloadArtifactTo baixa o artefato para path, que não pode existir ainda,
+// removendo o arquivo parcial em caso de falha.
+func (s *MCPServer) downloadArtifactTo(ctx context.Context, owner, repo string, artifactID int64, path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := s.github.DownloadArtifact(ctx, owner, repo, artifactID, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return written, nil
+}
+
+func (s *MCPServer) handleListRulesets(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	rulesets, err := s.github.ListRulesets(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(rulesets))
+	for _, ruleset := range rulesets {
+		items = append(items, listItem{
+			Title: fmt.Sprintf("%s (id %d)", ruleset.Name, ruleset.ID),
+			Fields: []field{
+				{"Aplicação", ruleset.Enforcement},
+				{"Alvo", ruleset.Target},
+				{"Origem", fmt.Sprintf("%s %s", ruleset.SourceType, ruleset.Source)},
 			},
-			{
-				Name:        "get_issues",
-				Description: "Listar issues de um repositório",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"owner": map[string]interface{}{
-							"type":        "string",
-							"description": "Proprietário do repositório",
-						},
-						"repo": map[string]interface{}{
-							"type":        "string",
-							"description": "Nome do repositório",
-						},
-					},
-					"required": []string{"owner", "repo"},
-				},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Rulesets de %s/%s (%d)", owner, repo, len(rulesets)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, rulesets[:limitCount(len(rulesets), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetRuleset(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	id, ok := intArg(params.Arguments, "ruleset_id")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "ruleset_id deve ser um número inteiro")
+	}
+
+	ruleset, err := s.github.GetRuleset(ctx, owner, repo, int64(id))
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	fields := []field{
+		{"Nome", ruleset.Name},
+		{"Aplicação", ruleset.Enforcement},
+		{"Alvo", ruleset.Target},
+	}
+	if ruleset.Conditions != nil {
+		fields = append(fields,
+			field{"Refs incluídas", strings.Join(ruleset.Conditions.RefName.Include, ", ")},
+			field{"Refs excluídas", strings.Join(ruleset.Conditions.RefName.Exclude, ", ")},
+		)
+	}
+	rules := make([]string, 0, len(ruleset.Rules))
+	for _, rule := range ruleset.Rules {
+		rules = append(rules, rule.Type)
+	}
+	fields = append(fields, field{"Regras", strings.Join(rules, ", ")})
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Ruleset %d de %s/%s", ruleset.ID, owner, repo), fields), ruleset)
+}
+
+func (s *MCPServer) handleCreateIssue(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	title, _ := params.Arguments["title"].(string)
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "title é obrigatório")
+	}
+	body, _ := params.Arguments["body"].(string)
+	labels := stringSliceArg(params.Arguments, "labels")
+
+	issue, err := s.github.CreateIssue(ctx, owner, repo, title, body, labels)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	fields := []field{{"Título", issue.Title}}
+	if len(labels) > 0 {
+		fields = append(fields, field{"Labels", strings.Join(labels, ", ")})
+	}
+	fields = append(fields, field{"URL", issue.HTMLURL})
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Issue #%d criada em %s/%s", issue.Number, owner, repo), fields), issue)
+}
+
+// handleUpdateIssueState atende close_issue e reopen_issue, que só diferem no
+// estado pedido.
+func (s *MCPServer) handleUpdateIssueState(ctx context.Context, msg MCPMessage, params CallToolParams, state string) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "number")
+	if !ok || number < 1 {
+		return errorResult(msg.ID, -32602, "Invalid params", "number deve ser um número inteiro positivo")
+	}
+	reason := scalarArg(params.Arguments, "state_reason")
+	if reason != "" && !containsString(issueStateReasons[state], reason) {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("state_reason deve ser %s", strings.Join(issueStateReasons[state], " ou ")))
+	}
+
+	issue, err := s.github.UpdateIssueStateWithReason(ctx, owner, repo, number, state, reason)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Issue #%d de %s/%s", issue.Number, owner, repo), []field{
+		{"Título", s.userText(issue.Title, params.Arguments)},
+		{"Estado", issue.State},
+		{"URL", issue.HTMLURL},
+	}), issue)
+}
+
+func (s *MCPServer) handleCreateIssueFromTemplate(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	name, _ := params.Arguments["template"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "template é obrigatório")
+	}
+	variables := make(map[string]string)
+	raw, _ := params.Arguments["variables"].(map[string]interface{})
+	for key := range raw {
+		variables[key] = scalarArg(raw, key)
+	}
+	title, _ := params.Arguments["title"].(string)
+	title = strings.TrimSpace(title)
+
+	template, err := s.github.GetIssueTemplate(ctx, owner, repo, name)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	var body string
+	var labels []string
+	if template == "" {
+		// Sem template: issue simples com as variáveis listadas no corpo.
+		keys := make([]string, 0, len(variables))
+		for key := range variables {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		for _, key := range keys {
+			b.WriteString(fmt.Sprintf("**%s:** %s\n", key, variables[key]))
+		}
+		body = b.String()
+	} else {
+		meta, rest := splitFrontMatter(template)
+		if title == "" {
+			title = meta["title"]
+		}
+		labels = frontMatterList(meta["labels"])
+		body = fillTemplate(rest, variables)
+	}
+	title = strings.TrimSpace(fillTemplate(title, variables))
+	if title == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "title é obrigatório quando o template não define um título")
+	}
+
+	issue, err := s.github.CreateIssue(ctx, owner, repo, title, body, labels)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	used := name
+	if template == "" {
+		used = "não encontrado; issue criada sem template"
+	}
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Issue #%d criada em %s/%s", issue.Number, owner, repo), []field{
+		{"Título", issue.Title},
+		{"Template", used},
+		{"Labels", strings.Join(labels, ", ")},
+		{"URL", issue.HTMLURL},
+	}), issue)
+}
+
+// splitFrontMatter separa o cabeçalho YAML (entre linhas "---") de um
+// template de issue do corpo. Só são lidos pares "chave: valor" e listas
+// "- item" de primeiro nível, o suficiente para title e labels.
+func splitFrontMatter(text string) (map[string]string, string) {
+	meta := make(map[string]string)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return meta, text
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return meta, text
+	}
+	header := text[4 : 4+end]
+	body := strings.TrimPrefix(text[4+end+4:], "\n")
+
+	var lastKey string
+	for _, line := range strings.Split(header, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "- ") && lastKey != "" {
+			item := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+			if meta[lastKey] != "" {
+				meta[lastKey] += ","
+			}
+			meta[lastKey] += item
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		lastKey = strings.TrimSpace(line[:i])
+		meta[lastKey] = unquote(strings.TrimSpace(line[i+1:]))
+	}
+	return meta, body
+}
+
+// frontMatterList interpreta listas do front matter escritas como
+// "a, b", "[a, b]" ou itens "- a" (já unidos por vírgula).
+func frontMatterList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "["), "]")
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = unquote(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// fillTemplate substitui os marcadores {{nome}} (ou {{ nome }}) pelos valores
+// informados; marcadores sem valor ficam como estão.
+func fillTemplate(text string, variables map[string]string) string {
+	for key, value := range variables {
+		text = strings.ReplaceAll(text, "{{"+key+"}}", value)
+		text = strings.ReplaceAll(text, "{{ "+key+" }}", value)
+	}
+	return text
+}
+
+// defaultStarHistoryPages limita quantas páginas de estrelas star_history lê
+// quando max_pages não é informado; repositórios populares têm milhares.
+const defaultStarHistoryPages = 10
+
+func (s *MCPServer) handleStarHistory(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	maxPages := defaultStarHistoryPages
+	if _, present := params.Arguments["max_pages"]; present {
+		n, ok := intArg(params.Arguments, "max_pages")
+		if !ok || n < 1 {
+			return errorResult(msg.ID, -32602, "Invalid params", "max_pages deve ser um inteiro positivo")
+		}
+		maxPages = n
+	}
+
+	stargazers, capped, err := s.github.GetStargazers(ctx, owner, repo, maxPages)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	perMonth := make(map[string]int)
+	for _, stargazer := range stargazers {
+		if len(stargazer.StarredAt) >= 7 {
+			perMonth[stargazer.StarredAt[:7]]++
+		}
+	}
+	months := make([]string, 0, len(perMonth))
+	for month := range perMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	fields := make([]field, 0, len(months)+1)
+	total := 0
+	for _, month := range months {
+		total += perMonth[month]
+		fields = append(fields, field{month, fmt.Sprintf("%d (+%d)", total, perMonth[month])})
+	}
+	if capped {
+		fields = append(fields, field{"Aviso", fmt.Sprintf("limite de %d páginas atingido; a série cobre só as %d estrelas mais antigas", maxPages, len(stargazers))})
+	}
+
+	header := fmt.Sprintf("Estrelas de %s/%s por mês (%d)", owner, repo, len(stargazers))
+	return textResult(msg.ID, s.renderDetails(header, fields))
+}
+
+// defaultComparePages limita quantas páginas compare_commits lê quando
+// max_pages não é informado.
+const defaultComparePages = 10
+
+func (s *MCPServer) handleCompareCommits(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	base, _ := params.Arguments["base"].(string)
+	head, _ := params.Arguments["head"].(string)
+	base, head = strings.TrimSpace(base), strings.TrimSpace(head)
+	if base == "" || head == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "base e head são obrigatórios")
+	}
+	maxPages := defaultComparePages
+	if _, present := params.Arguments["max_pages"]; present {
+		n, ok := intArg(params.Arguments, "max_pages")
+		if !ok || n < 1 {
+			return errorResult(msg.ID, -32602, "Invalid params", "max_pages deve ser um inteiro positivo")
+		}
+		maxPages = n
+	}
+
+	comparison, capped, err := s.github.CompareCommitsPaged(ctx, owner, repo, base, head, maxPages)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	fields := []field{
+		{"Estado", comparison.Status},
+		{"À frente", strconv.Itoa(comparison.AheadBy)},
+		{"Atrás", strconv.Itoa(comparison.BehindBy)},
+		{"Commits", fmt.Sprintf("%d de %d", len(comparison.Commits), comparison.TotalCommits)},
+		{"Arquivos", strconv.Itoa(len(comparison.Files))},
+		{"URL", comparison.HTMLURL},
+	}
+	if capped {
+		fields = append(fields, field{"Aviso", fmt.Sprintf("limite de %d páginas atingido; commits e arquivos podem estar incompletos", maxPages)})
+	}
+	if len(comparison.Files) >= compareFileLimit {
+		fields = append(fields, field{"Aviso", fmt.Sprintf("o GitHub devolve no máximo %d arquivos por comparação; a lista foi cortada pela API", compareFileLimit)})
+	}
+
+	items := make([]listItem, 0, len(comparison.Files))
+	for _, file := range comparison.Files {
+		items = append(items, listItem{
+			Title:  file.Filename,
+			Fields: []field{{"Estado", file.Status}, {"Alterações", fmt.Sprintf("+%d -%d", file.Additions, file.Deletions)}},
+		})
+	}
+
+	var result strings.Builder
+	result.WriteString(s.renderDetails(fmt.Sprintf("Comparação %s...%s em %s/%s", base, head, owner, repo), fields))
+	result.WriteString("\n")
+	result.WriteString(s.renderList(fmt.Sprintf("Arquivos alterados (%d)", len(comparison.Files)), limitItems(items, params.Arguments)))
+	return s.outputResult(msg.ID, params.Arguments, result.String(), comparison)
+}
+
+func (s *MCPServer) handleSetRepoVisibility(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	visibility, _ := params.Arguments["visibility"].(string)
+	visibility = strings.ToLower(strings.TrimSpace(visibility))
+	if !validVisibility(visibility) {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("visibility inválida: %q (use public, private ou internal)", visibility))
+	}
+
+	repository, err := s.github.SetRepoVisibility(ctx, owner, repo, visibility)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Visibilidade de %s", repository.FullName), []field{
+		{"Visibilidade", repository.Visibility},
+		{"URL", repository.HTMLURL},
+	}), repository)
+}
+
+func (s *MCPServer) handleListEnvironments(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	environments, err := s.github.ListEnvironments(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	header := fmt.Sprintf("Ambientes de %s/%s (%d)", owner, repo, len(environments))
+	if len(environments) == 0 {
+		return s.outputResult(msg.ID, params.Arguments, s.renderDetails(header, []field{{"Ambientes", "nenhum ambiente configurado"}}), environments)
+	}
+
+	items := make([]listItem, 0, len(environments))
+	for _, environment := range environments {
+		var rules []string
+		for _, rule := range environment.ProtectionRules {
+			switch rule.Type {
+			case "wait_timer":
+				rules = append(rules, fmt.Sprintf("espera de %d min", rule.WaitTimer))
+			case "required_reviewers":
+				rules = append(rules, fmt.Sprintf("%d revisor(es) obrigatório(s)", len(rule.Reviewers)))
+			default:
+				rules = append(rules, rule.Type)
+			}
+		}
+		if policy := environment.DeploymentBranchPolicy; policy != nil {
+			if policy.ProtectedBranches {
+				rules = append(rules, "só branches protegidas")
+			} else if policy.CustomBranchPolicies {
+				rules = append(rules, "branches selecionadas")
+			}
+		}
+		protection := strings.Join(rules, ", ")
+		if protection == "" {
+			protection = "nenhuma"
+		}
+		items = append(items, listItem{
+			Title:  environment.Name,
+			URL:    environment.HTMLURL,
+			Fields: []field{{"Proteção", protection}},
+		})
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderList(header, limitItems(items, params.Arguments)), environments[:limitCount(len(environments), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetRepoActivity(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	activityType, _ := params.Arguments["activity_type"].(string)
+	activityType = strings.TrimSpace(activityType)
+
+	activity, err := s.github.GetRepoActivity(ctx, owner, repo, activityType)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(activity))
+	for _, entry := range activity {
+		actor := "(usuário removido)"
+		if entry.Actor != nil {
+			actor = entry.Actor.Login
+		}
+		items = append(items, listItem{
+			Title: fmt.Sprintf("%s em %s", entry.ActivityType, strings.TrimPrefix(entry.Ref, "refs/heads/")),
+			Fields: []field{
+				{"Autor", actor},
+				{"Data", entry.Timestamp},
+				{"Commits", fmt.Sprintf("%s → %s", shortSHA(entry.Before), shortSHA(entry.After))},
+			},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Atividade recente de %s/%s (%d)", owner, repo, len(activity)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, activity[:limitCount(len(activity), params.Arguments)])
+}
+
+func (s *MCPServer) handleOrgReposSummary(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	org, _ := params.Arguments["org"].(string)
+	org = strings.TrimSpace(org)
+	if org == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "org é obrigatório")
+	}
+
+	organization, err := s.github.GetOrg(ctx, org)
+	var repos []GitHubRepo
+	if err == nil {
+		repos, err = s.github.GetOrgReposPage(ctx, org)
+	}
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	total := fmt.Sprintf("%d públicos", organization.PublicRepos)
+	if organization.TotalPrivateRepos != nil {
+		total = fmt.Sprintf("%d (%d públicos, %d privados)", organization.PublicRepos+*organization.TotalPrivateRepos, organization.PublicRepos, *organization.TotalPrivateRepos)
+	}
+
+	var result strings.Builder
+	result.WriteString(s.renderDetails(fmt.Sprintf("Organização %s", organization.Login), []field{
+		{"Nome", organization.Name},
+		{"Repositórios", total},
+		{"URL", organization.HTMLURL},
+	}))
+	result.WriteString("\n")
+	result.WriteString(s.renderList(fmt.Sprintf("Primeira página de repositórios (%d)", len(repos)), limitItems(repoItems(repos), params.Arguments)))
+	return textResult(msg.ID, result.String())
+}
+
+func (s *MCPServer) handleGetCheckSuites(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	ref, _ := params.Arguments["ref"].(string)
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "ref é obrigatório")
+	}
+
+	suites, err := s.github.GetCheckSuites(ctx, owner, repo, ref)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(suites))
+	for _, suite := range suites {
+		app := "(app desconhecido)"
+		if suite.App != nil {
+			app = suite.App.Name
+		}
+		conclusion := suite.Conclusion
+		if conclusion == "" {
+			conclusion = "pendente"
+		}
+		items = append(items, listItem{
+			Title:  fmt.Sprintf("%s (id %d)", app, suite.ID),
+			Fields: []field{{"Estado", suite.Status}, {"Conclusão", conclusion}},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Check suites de %s/%s em %s (%d)", owner, repo, ref, len(suites)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, suites[:limitCount(len(suites), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetPages(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	pages, err := s.github.GetPages(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	header := fmt.Sprintf("GitHub Pages de %s/%s", owner, repo)
+	if pages == nil {
+		// structuredContent vai com os campos vazios: status "" indica que o
+		// Pages não está habilitado.
+		return s.outputResult(msg.ID, params.Arguments, s.renderDetails(header, []field{{"Estado", "GitHub Pages não está habilitado neste repositório"}}), GitHubPages{})
+	}
+
+	https := "não"
+	if pages.HTTPSEnforced {
+		https = "sim"
+	}
+	fields := []field{
+		{"Estado", pages.Status},
+		{"URL", pages.HTMLURL},
+	}
+	if pages.CNAME != "" {
+		fields = append(fields, field{"Domínio", pages.CNAME})
+	}
+	if pages.BuildType != "" {
+		fields = append(fields, field{"Build", pages.BuildType})
+	}
+	if pages.Source != nil {
+		fields = append(fields, field{"Origem", pages.Source.Branch + ":" + pages.Source.Path})
+	}
+	fields = append(fields, field{"HTTPS obrigatório", https})
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(header, fields), pages)
+}
+
+func (s *MCPServer) handleClosePullRequest(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "pr_number")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "pr_number deve ser um número inteiro")
+	}
+	state := "closed"
+	if value, _ := params.Arguments["state"].(string); strings.TrimSpace(value) != "" {
+		state = strings.ToLower(strings.TrimSpace(value))
+	}
+	if state != "open" && state != "closed" {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("state inválido: %q (use closed ou open)", state))
+	}
+
+	pr, err := s.github.UpdatePullRequest(ctx, owner, repo, number, state)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("PR #%d de %s/%s", pr.Number, owner, repo), []field{
+		{"Título", s.userText(pr.Title, params.Arguments)},
+		{"Estado", pr.State},
+		{"URL", pr.HTMLURL},
+	}), pr)
+}
+
+func (s *MCPServer) handleRequestReviewers(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "pr_number")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "pr_number deve ser um número inteiro")
+	}
+	reviewers := stringSliceArg(params.Arguments, "reviewers")
+	teams := stringSliceArg(params.Arguments, "team_reviewers")
+	if len(reviewers) == 0 && len(teams) == 0 {
+		return errorResult(msg.ID, -32602, "Invalid params", "informe reviewers e/ou team_reviewers")
+	}
+
+	request, err := s.github.RequestReviewers(ctx, owner, repo, number, reviewers, teams)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	users := make([]string, 0, len(request.RequestedReviewers))
+	for _, user := range request.RequestedReviewers {
+		users = append(users, user.Login)
+	}
+	slugs := make([]string, 0, len(request.RequestedTeams))
+	for _, team := range request.RequestedTeams {
+		slugs = append(slugs, team.Slug)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Revisores pendentes do PR #%d de %s/%s", request.Number, owner, repo), []field{
+		{"Usuários", strings.Join(users, ", ")},
+		{"Times", strings.Join(slugs, ", ")},
+		{"URL", request.HTMLURL},
+	}), request)
+}
+
+func (s *MCPServer) handleGetRepo(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	repository, err := s.github.GetRepo(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	fields := []field{
+		{"Descrição", repository.Description},
+		{"Visibilidade", repository.Visibility},
+		{"Linguagem", repository.Language},
+		{"Branch padrão", repository.DefaultBranch},
+		{"Stars", fmt.Sprint(repository.StargazersCount)},
+		{"Forks", fmt.Sprint(repository.ForksCount)},
+		{"Tópicos", strings.Join(repository.Topics, ", ")},
+		{"URL", repository.HTMLURL},
+	}
+	if repository.Fork {
+		if repository.Parent != nil {
+			fields = append(fields, field{"Fork de", fmt.Sprintf("%s (%s)", repository.Parent.FullName, repository.Parent.HTMLURL)})
+		} else {
+			fields = append(fields, field{"Fork de", "(repositório original indisponível)"})
+		}
+		// Em forks de forks, a origem da rede é diferente do pai imediato.
+		if repository.Source != nil && (repository.Parent == nil || repository.Source.FullName != repository.Parent.FullName) {
+			fields = append(fields, field{"Origem da rede", fmt.Sprintf("%s (%s)", repository.Source.FullName, repository.Source.HTMLURL)})
+		}
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(repository.FullName, fields), repository)
+}
+
+// defaultProfileRepos é quantos repositórios user_language_profile analisa
+// quando max_repos não é informado.
+const defaultProfileRepos = 30
+
+func (s *MCPServer) handleUserLanguageProfile(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	username, _ := params.Arguments["username"].(string)
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "username é obrigatório")
+	}
+	maxRepos := defaultProfileRepos
+	if value, ok := intArg(params.Arguments, "max_repos"); ok {
+		if value < 1 {
+			return errorResult(msg.ID, -32602, "Invalid params", "max_repos deve ser maior que zero")
+		}
+		maxRepos = value
+	}
+	includeForks := boolArg(params.Arguments, "include_forks")
+
+	repos, err := s.github.GetRepos(ctx, username, PageOptions{})
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	selected := make([]GitHubRepo, 0, len(repos))
+	for _, repo := range repos {
+		if repo.Fork && !includeForks {
+			continue
+		}
+		if len(selected) == maxRepos {
+			break
+		}
+		selected = append(selected, repo)
+	}
+
+	type repoLanguages struct {
+		languages map[string]int
+		err       error
+	}
+	results := make([]repoLanguages, len(selected))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRequests)
+	for i, repo := range selected {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			languages, err := s.github.GetLanguages(ctx, username, name)
+			results[i] = repoLanguages{languages: languages, err: err}
+		}(i, repo.Name)
+	}
+	wg.Wait()
+
+	totals := map[string]int{}
+	repoCounts := map[string]int{}
+	total, withoutLanguage := 0, 0
+	var failed []string
+	for i, result := range results {
+		if result.err != nil {
+			failed = append(failed, selected[i].Name)
+			continue
+		}
+		if len(result.languages) == 0 {
+			withoutLanguage++
+			continue
+		}
+		for name, bytes := range result.languages {
+			totals[name] += bytes
+			repoCounts[name]++
+			total += bytes
+		}
+	}
+
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if totals[names[i]] != totals[names[j]] {
+			return totals[names[i]] > totals[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	items := make([]listItem, 0, len(names))
+	for _, name := range names {
+		items = append(items, listItem{
+			Title: name,
+			Fields: []field{
+				{"Bytes", fmt.Sprint(totals[name])},
+				{"Percentual", fmt.Sprintf("%.1f%%", float64(totals[name])*100/float64(total))},
+				{"Repositórios", fmt.Sprint(repoCounts[name])},
 			},
-			{
-				Name:        "get_pull_requests",
-				Description: "Listar pull requests de um repositório",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"owner": map[string]interface{}{
-							"type":        "string",
-							"description": "Proprietário do repositório",
-						},
-						"repo": map[string]interface{}{
-							"type":        "string",
-							"description": "Nome do repositório",
-						},
-					},
-					"required": []string{"owner", "repo"},
-				},
+		})
+	}
+
+	var result strings.Builder
+	result.WriteString(s.renderList(fmt.Sprintf("Perfil de linguagens de %s (%d repositórios analisados)", username, len(selected)), limitItems(items, params.Arguments)))
+	var notes []field
+	if withoutLanguage > 0 {
+		notes = append(notes, field{"Sem linguagem detectada", fmt.Sprintf("%d repositórios", withoutLanguage)})
+	}
+	if len(failed) > 0 {
+		notes = append(notes, field{"Falha ao obter linguagens", strings.Join(failed, ", ")})
+	}
+	if len(notes) > 0 {
+		result.WriteString("\n")
+		result.WriteString(s.renderDetails("Observações", notes))
+	}
+
+	return textResult(msg.ID, result.String())
+}
+
+func (s *MCPServer) handleGetIssueComments(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "number")
+	if !ok || number < 1 {
+		return errorResult(msg.ID, -32602, "Invalid params", "number deve ser um número inteiro positivo")
+	}
+
+	comments, err := s.github.GetIssueComments(ctx, owner, repo, number)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	shown := comments[:limitCount(len(comments), params.Arguments)]
+
+	var result strings.Builder
+	for _, comment := range shown {
+		result.WriteString("\n")
+		result.WriteString(s.renderDetails(comment.User.Login, []field{
+			{"Data", comment.CreatedAt},
+			{"URL", comment.HTMLURL},
+		}))
+		result.WriteString(s.renderBlock("Comentário", s.userText(comment.Body, params.Arguments)))
+		result.WriteString("\n")
+	}
+
+	header := fmt.Sprintf("Comentários de #%d em %s/%s (%d)", number, owner, repo, len(comments))
+	return s.outputResult(msg.ID, params.Arguments, header+":\n"+result.String(), shown)
+}
+
+func (s *MCPServer) handleCreateIssueComment(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	number, ok := intArg(params.Arguments, "number")
+	if !ok || number < 1 {
+		return errorResult(msg.ID, -32602, "Invalid params", "number deve ser um número inteiro positivo")
+	}
+	body, _ := params.Arguments["body"].(string)
+	if strings.TrimSpace(body) == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "body é obrigatório")
+	}
+
+	comment, err := s.github.CreateIssueComment(ctx, owner, repo, number, body)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Comentário %d criado em #%d de %s/%s", comment.ID, number, owner, repo), []field{
+		{"Autor", comment.User.Login},
+		{"URL", comment.HTMLURL},
+	}), comment)
+}
+
+func (s *MCPServer) handleUpdateIssueComment(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	id, ok := intArg(params.Arguments, "comment_id")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "comment_id deve ser um número inteiro")
+	}
+	body, _ := params.Arguments["body"].(string)
+	if strings.TrimSpace(body) == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "body é obrigatório")
+	}
+
+	comment, err := s.github.UpdateIssueComment(ctx, owner, repo, int64(id), body)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("Comentário %d de %s/%s atualizado", comment.ID, owner, repo), []field{
+		{"Autor", comment.User.Login},
+		{"Atualizado em", comment.UpdatedAt},
+		{"URL", comment.HTMLURL},
+	}), comment)
+}
+
+func (s *MCPServer) handleDeleteIssueComment(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	id, ok := intArg(params.Arguments, "comment_id")
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", "comment_id deve ser um número inteiro")
+	}
+
+	if err := s.github.DeleteIssueComment(ctx, owner, repo, int64(id)); err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return textResult(msg.ID, fmt.Sprintf("Comentário %d de %s/%s removido.\n", id, owner, repo))
+}
+
+// defaultLargestFiles é quantos arquivos largest_files devolve sem limit.
+const defaultLargestFiles = 20
+
+func (s *MCPServer) handleLargestFiles(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	limit := defaultLargestFiles
+	if value, ok := intArg(params.Arguments, "limit"); ok {
+		if value < 1 {
+			return errorResult(msg.ID, -32602, "Invalid params", "limit deve ser maior que zero")
+		}
+		limit = value
+	}
+	ref, _ := params.Arguments["ref"].(string)
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		ref, err = s.github.DefaultBranch(ctx, owner, repo)
+		if err != nil {
+			return githubErrorResult(msg.ID, err)
+		}
+	}
+
+	tree, err := s.github.GetTree(ctx, owner, repo, ref, true)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	blobs := make([]GitHubTreeEntry, 0, len(tree.Tree))
+	var total int64
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" {
+			blobs = append(blobs, entry)
+			total += entry.Size
+		}
+	}
+	sort.Slice(blobs, func(i, j int) bool {
+		if blobs[i].Size != blobs[j].Size {
+			return blobs[i].Size > blobs[j].Size
+		}
+		return blobs[i].Path < blobs[j].Path
+	})
+	if len(blobs) > limit {
+		blobs = blobs[:limit]
+	}
+
+	items := make([]listItem, 0, len(blobs))
+	for _, blob := range blobs {
+		items = append(items, listItem{
+			Title:  blob.Path,
+			Fields: []field{{"Tamanho", formatBytes(blob.Size)}},
+		})
+	}
+
+	var result strings.Builder
+	result.WriteString(s.renderList(fmt.Sprintf("Maiores arquivos de %s/%s em %s (total analisado: %s)", owner, repo, ref, formatBytes(total)), items))
+	if tree.Truncated {
+		result.WriteString("\n[árvore truncada pela API do GitHub: a análise está incompleta e arquivos grandes podem ter ficado de fora]\n")
+	}
+
+	return textResult(msg.ID, result.String())
+}
+
+// formatBytes formata um tamanho em bytes com a maior unidade binária que
+// mantém o valor >= 1.
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, suffixes[i])
+}
+
+// maxPatchSize limita o patch de cada arquivo em get_pr_files, para que um
+// arquivo enorme não ocupe a resposta inteira.
+const maxPatchSize = 16 * 1024
+
+func (s *MCPServer) handleGetPRFiles(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	// get_pull_request_files é a mesma ferramenta com o número em number,
+	// como no endpoint pulls/{number}/files.
+	numberArg := "pr_number"
+	if params.Name == "get_pull_request_files" {
+		numberArg = "number"
+	}
+	number, ok := intArg(params.Arguments, numberArg)
+	if !ok {
+		return errorResult(msg.ID, -32602, "Invalid params", numberArg+" deve ser um número inteiro")
+	}
+	filter, _ := params.Arguments["path_filter"].(string)
+	filter = strings.TrimSpace(filter)
+	if filter != "" {
+		if _, err := path.Match(filter, ""); err != nil {
+			return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("path_filter inválido: %q", filter))
+		}
+	}
+
+	files, err := s.github.GetPullRequestFiles(ctx, owner, repo, number)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	var result strings.Builder
+	var shown []GitHubFile
+	matched := 0
+	limit := limitArg(params.Arguments)
+	for _, file := range files {
+		if filter != "" && !matchPath(filter, file.Filename) {
+			continue
+		}
+		matched++
+		if limit > 0 && matched > limit {
+			continue
+		}
+		shown = append(shown, file)
+
+		result.WriteString("\n")
+		result.WriteString(s.renderDetails(file.Filename, []field{
+			{"Estado", file.Status},
+			{"Alterações", fmt.Sprintf("+%d -%d", file.Additions, file.Deletions)},
+		}))
+		if file.Patch == "" {
+			result.WriteString("[sem patch: arquivo binário ou grande demais para o GitHub]\n")
+			continue
+		}
+		patch, truncated := truncateText(file.Patch, maxPatchSize)
+		result.WriteString(s.renderBlock("Patch", patch))
+		result.WriteString("\n")
+		if truncated {
+			result.WriteString(fmt.Sprintf("[patch truncado em %d bytes]\n", maxPatchSize))
+		}
+	}
+
+	header := fmt.Sprintf("Arquivos do PR #%d de %s/%s (%d)", number, owner, repo, matched)
+	if filter != "" {
+		header = fmt.Sprintf("Arquivos do PR #%d de %s/%s que casam com %s (%d de %d)", number, owner, repo, filter, matched, len(files))
+	}
+	return s.outputResult(msg.ID, params.Arguments, header+":\n"+result.String(), shown)
+}
+
+// matchPath diz se o glob pattern casa com o caminho completo de name ou,
+// para padrões sem barra, com o nome base do arquivo.
+func matchPath(pattern, name string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return false
+}
+
+// handleServerInfo descreve o servidor a partir do próprio estado, sem chamar
+// a API. Só o host da URL base é exposto; o token nunca aparece.
+func (s *MCPServer) handleServerInfo(msg MCPMessage) MCPMessage {
+	host := s.github.baseURL
+	if u, err := url.Parse(s.github.baseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	tools := make([]string, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, s.toolPrefix+tool.Name)
+	}
+	transports := []string{"stdio"}
+
+	text := s.renderDetails(serverName, []field{
+		{"Versão", serverVersion},
+		{"Protocolo MCP", protocolVersion},
+		{"Host da API", host},
+		{"Transportes", strings.Join(transports, ", ")},
+		{"Somente leitura", fmt.Sprint(s.readOnly)},
+		{"Ferramentas", fmt.Sprintf("%d: %s", len(tools), strings.Join(tools, ", "))},
+	})
+
+	return structuredResult(msg.ID, text, map[string]interface{}{
+		"name":             serverName,
+		"version":          serverVersion,
+		"protocol_version": protocolVersion,
+		"api_host":         host,
+		"transports":       transports,
+		"read_only":        s.readOnly,
+		"tool_count":       len(tools),
+		"tools":            tools,
+	})
+}
+
+func (s *MCPServer) handleRepoWorkItems(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	var (
+		wg                  sync.WaitGroup
+		issues              []GitHubIssue
+		prs                 []GitHubPR
+		issuesErr, pullsErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		issues, issuesErr = s.github.GetIssues(ctx, owner, repo, IssueFilter{}, PageOptions{})
+	}()
+	go func() {
+		defer wg.Done()
+		prs, pullsErr = s.github.GetPullRequests(ctx, owner, repo, PageOptions{})
+	}()
+	wg.Wait()
+
+	// Uma falha parcial ainda devolve a outra seção; só é erro se as duas
+	// falharem.
+	if issuesErr != nil && pullsErr != nil {
+		return githubErrorResult(msg.ID, fmt.Errorf("issues: %w; pull requests: %w", issuesErr, pullsErr))
+	}
+
+	var result strings.Builder
+	if issuesErr != nil {
+		result.WriteString(s.renderDetails("Issues abertas", []field{{"Erro", issuesErr.Error()}}))
+	} else {
+		items := make([]listItem, 0, len(issues))
+		for _, issue := range issues {
+			items = append(items, listItem{
+				Title: fmt.Sprintf("#%d: %s", issue.Number, s.userText(issue.Title, params.Arguments)),
+				URL:   issue.HTMLURL,
+			})
+		}
+		result.WriteString(s.renderList(fmt.Sprintf("Issues abertas (%d)", len(items)), limitItems(items, params.Arguments)))
+	}
+	result.WriteString("\n")
+
+	if pullsErr != nil {
+		result.WriteString(s.renderDetails("Pull requests abertos", []field{{"Erro", pullsErr.Error()}}))
+	} else {
+		items := make([]listItem, 0, len(prs))
+		for _, pr := range prs {
+			items = append(items, listItem{
+				Title: fmt.Sprintf("#%d: %s", pr.Number, s.userText(pr.Title, params.Arguments)),
+				URL:   pr.HTMLURL,
+			})
+		}
+		result.WriteString(s.renderList(fmt.Sprintf("Pull requests abertos (%d)", len(items)), limitItems(items, params.Arguments)))
+	}
+
+	return textResult(msg.ID, fmt.Sprintf("Itens de trabalho de %s/%s\n\n", owner, repo)+result.String())
+}
+
+func (s *MCPServer) handleResolveRef(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	ref, _ := params.Arguments["ref"].(string)
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "ref é obrigatório")
+	}
+
+	sha, kind, err := s.github.resolveRef(ctx, owner, repo, ref)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return textResult(msg.ID, s.renderDetails(fmt.Sprintf("Ref %s de %s/%s", ref, owner, repo), []field{
+		{"Tipo", kind},
+		{"SHA", sha},
+	}))
+}
+
+func (s *MCPServer) handleDiffStats(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	sha, _ := params.Arguments["sha"].(string)
+	base, _ := params.Arguments["base"].(string)
+	head, _ := params.Arguments["head"].(string)
+	sha, base, head = strings.TrimSpace(sha), strings.TrimSpace(base), strings.TrimSpace(head)
+
+	var (
+		header               string
+		additions, deletions int
+		files                int
+		warning              string
+	)
+	switch {
+	case sha != "" && base == "" && head == "":
+		detail, err := s.github.GetCommitDetail(ctx, owner, repo, sha)
+		if err != nil {
+			return githubErrorResult(msg.ID, err)
+		}
+		header = fmt.Sprintf("Estatísticas do commit %s de %s/%s", shortSHA(detail.SHA), owner, repo)
+		additions, deletions, files = detail.Stats.Additions, detail.Stats.Deletions, len(detail.Files)
+
+	case sha == "" && base != "" && head != "":
+		comparison, capped, err := s.github.CompareCommitsPaged(ctx, owner, repo, base, head, defaultComparePages)
+		if err != nil {
+			return githubErrorResult(msg.ID, err)
+		}
+		// A comparação não traz totais prontos; eles são somados dos arquivos.
+		for _, file := range comparison.Files {
+			additions += file.Additions
+			deletions += file.Deletions
+		}
+		files = len(comparison.Files)
+		header = fmt.Sprintf("Estatísticas de %s...%s em %s/%s", base, head, owner, repo)
+		if capped || files >= compareFileLimit {
+			warning = "a lista de arquivos da comparação foi cortada; os totais podem estar incompletos"
+		}
+
+	default:
+		return errorResult(msg.ID, -32602, "Invalid params", "informe sha ou base e head")
+	}
+
+	fields := []field{
+		{"Adições", fmt.Sprint(additions)},
+		{"Remoções", fmt.Sprint(deletions)},
+		{"Arquivos alterados", fmt.Sprint(files)},
+	}
+	if warning != "" {
+		fields = append(fields, field{"Aviso", warning})
+	}
+
+	return textResult(msg.ID, s.renderDetails(header, fields))
+}
+
+func (s *MCPServer) handleGetZen(ctx context.Context, msg MCPMessage) MCPMessage {
+	zen, err := s.github.GetZen(ctx)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return textResult(msg.ID, zen)
+}
+
+func (s *MCPServer) handleRenderMarkdown(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	text, _ := params.Arguments["text"].(string)
+	if strings.TrimSpace(text) == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "text é obrigatório")
+	}
+	mode, _ := params.Arguments["mode"].(string)
+	mode = strings.TrimSpace(mode)
+	if mode != "" && mode != "markdown" && mode != "gfm" {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("mode inválido: %q (use markdown ou gfm)", mode))
+	}
+	repoContext, _ := params.Arguments["context"].(string)
+	repoContext = strings.TrimSpace(repoContext)
+	if repoContext != "" {
+		owner, repo, ok := parseRepoRef(repoContext)
+		if !ok {
+			return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("context inválido: %q (use owner/repo)", repoContext))
+		}
+		repoContext = owner + "/" + repo
+	}
+
+	html, err := s.github.RenderMarkdown(ctx, text, mode, repoContext)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	html, truncated := truncateText(html, maxContentSize)
+	if truncated {
+		html += fmt.Sprintf("\n[HTML truncado em %d bytes]\n", maxContentSize)
+	}
+	return textResult(msg.ID, html)
+}
+
+func (s *MCPServer) handleGetFollowers(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	username, _ := params.Arguments["username"].(string)
+
+	followers, err := s.github.GetFollowers(ctx, username)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	header := fmt.Sprintf("Seguidores (%d)", len(followers))
+	if username != "" {
+		header = fmt.Sprintf("Seguidores de %s (%d)", username, len(followers))
+	}
+	return s.outputResult(msg.ID, params.Arguments, s.renderList(header, limitItems(userItems(followers), params.Arguments)), followers[:limitCount(len(followers), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetFollowing(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	username, _ := params.Arguments["username"].(string)
+
+	following, err := s.github.GetFollowing(ctx, username)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	header := fmt.Sprintf("Seguindo (%d)", len(following))
+	if username != "" {
+		header = fmt.Sprintf("%s segue (%d)", username, len(following))
+	}
+	return s.outputResult(msg.ID, params.Arguments, s.renderList(header, limitItems(userItems(following), params.Arguments)), following[:limitCount(len(following), params.Arguments)])
+}
+
+func (s *MCPServer) handleRepoSummary(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	var (
+		wg         sync.WaitGroup
+		details    *GitHubRepo
+		languages  map[string]int
+		release    *GitHubRelease
+		openIssues int
+
+		detailsErr, languagesErr, releaseErr, issuesErr error
+	)
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		details, detailsErr = s.github.GetRepo(ctx, owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		languages, languagesErr = s.github.GetLanguages(ctx, owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		release, releaseErr = s.github.GetLatestRelease(ctx, owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		openIssues, issuesErr = s.github.CountOpenIssues(ctx, owner, repo)
+	}()
+	wg.Wait()
+
+	var fields []field
+	if detailsErr != nil {
+		fields = append(fields, field{"Detalhes", "erro ao obter: " + detailsErr.Error()})
+	} else {
+		fields = append(fields,
+			field{"Descrição", details.Description},
+			field{"Linguagem principal", details.Language},
+			field{"Branch padrão", details.DefaultBranch},
+			field{"Estrelas", fmt.Sprint(details.StargazersCount)},
+			field{"Forks", fmt.Sprint(details.ForksCount)},
+			field{"Privado", fmt.Sprint(details.Private)},
+		)
+		if len(details.Topics) > 0 {
+			fields = append(fields, field{"Tópicos", strings.Join(details.Topics, ", ")})
+		}
+	}
+
+	switch {
+	case languagesErr != nil:
+		fields = append(fields, field{"Linguagens", "erro ao obter: " + languagesErr.Error()})
+	case len(languages) == 0:
+		fields = append(fields, field{"Linguagens", "nenhuma detectada"})
+	default:
+		fields = append(fields, field{"Linguagens", formatLanguageShares(languages)})
+	}
+
+	switch {
+	case releaseErr != nil:
+		fields = append(fields, field{"Última release", "erro ao obter: " + releaseErr.Error()})
+	case release == nil:
+		fields = append(fields, field{"Última release", "nenhuma release publicada"})
+	default:
+		fields = append(fields, field{"Última release", fmt.Sprintf("%s (%s)", release.TagName, release.PublishedAt)})
+	}
+
+	if issuesErr != nil {
+		fields = append(fields, field{"Issues abertas", "erro ao obter: " + issuesErr.Error()})
+	} else {
+		fields = append(fields, field{"Issues abertas", fmt.Sprint(openIssues)})
+	}
+
+	if detailsErr == nil {
+		fields = append(fields, field{"URL", details.HTMLURL})
+	}
+
+	return textResult(msg.ID, s.renderDetails(fmt.Sprintf("Resumo de %s/%s", owner, repo), fields))
+}
+
+func (s *MCPServer) handleListRepoInvitations(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	invitations, err := s.github.ListRepoInvitations(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(invitations))
+	for _, invitation := range invitations {
+		items = append(items, listItem{
+			Title: invitation.Invitee.Login,
+			URL:   invitation.HTMLURL,
+			Fields: []field{
+				{"Permissão", invitation.Permissions},
+				{"Convidado por", invitation.Inviter.Login},
+				{"Criado em", invitation.CreatedAt},
 			},
-			{
-				Name:        "get_commits",
-				Description: "Listar commits de um repositório",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"owner": map[string]interface{}{
-							"type":        "string",
-							"description": "Proprietário do repositório",
-						},
-						"repo": map[string]interface{}{
-							"type":        "string",
-							"description": "Nome do repositório",
-						},
-					},
-					"required": []string{"owner", "repo"},
-				},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Convites pendentes em %s/%s (%d)", owner, repo, len(invitations)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, invitations[:limitCount(len(invitations), params.Arguments)])
+}
+
+func (s *MCPServer) handleGetCloneURLs(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	details, err := s.github.GetRepo(ctx, owner, repo)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.outputResult(msg.ID, params.Arguments, s.renderDetails(fmt.Sprintf("URLs de clone de %s", details.FullName), []field{
+		{"HTTPS", details.CloneURL},
+		{"SSH", details.SSHURL},
+		{"Git", details.GitURL},
+		{"Branch padrão", details.DefaultBranch},
+	}), details)
+}
+
+func (s *MCPServer) handleGrepRepo(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	term, _ := params.Arguments["query"].(string)
+	if strings.TrimSpace(term) == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "query é obrigatório")
+	}
+
+	result, err := s.github.GrepRepo(ctx, owner, repo, term)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(result.Items))
+	for _, item := range result.Items {
+		var fields []field
+		for _, match := range item.TextMatches {
+			fields = append(fields, field{"Trecho", strings.Join(strings.Fields(match.Fragment), " ")})
+		}
+		items = append(items, listItem{Title: item.Path, URL: item.HTMLURL, Fields: fields})
+	}
+
+	header := fmt.Sprintf("Resultados para %q em %s/%s (%d de %d)", term, owner, repo, len(result.Items), result.TotalCount)
+	text := s.renderList(header, limitItems(items, params.Arguments))
+	if result.IncompleteResults {
+		text += "\nAviso: a busca expirou no GitHub e os resultados podem estar incompletos.\n"
+	}
+	return textResult(msg.ID, text)
+}
+
+func (s *MCPServer) handleSearchRepositories(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	q, _ := params.Arguments["query"].(string)
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "query é obrigatório")
+	}
+	sort, _ := params.Arguments["sort"].(string)
+	sort = strings.TrimSpace(sort)
+	if sort != "" && !containsString(repoSearchSorts, sort) {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("sort inválido: %q (use %s)", sort, strings.Join(repoSearchSorts, ", ")))
+	}
+	order, _ := params.Arguments["order"].(string)
+	order = strings.TrimSpace(order)
+	if order != "" && !containsString(repoSearchOrders, order) {
+		return errorResult(msg.ID, -32602, "Invalid params", fmt.Sprintf("order inválido: %q (use %s)", order, strings.Join(repoSearchOrders, ", ")))
+	}
+
+	repos, err := s.github.SearchRepositories(ctx, q, sort, order)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(repos))
+	for _, repo := range repos {
+		items = append(items, listItem{
+			Title: repo.FullName,
+			URL:   repo.HTMLURL,
+			Fields: []field{
+				{"Descrição", repo.Description},
+				{"Linguagem", repo.Language},
+				{"Estrelas", strconv.Itoa(repo.StargazersCount)},
+				{"Atualizado em", repo.UpdatedAt},
 			},
-			{
-				Name:        "get_content",
-				Description: "Obter conteúdo de um arquivo no repositório",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"owner": map[string]interface{}{
-							"type":        "string",
-							"description": "Proprietário do repositório",
-						},
-						"repo": map[string]interface{}{
-							"type":        "string",
-							"description": "Nome do repositório",
-						},
-						"path": map[string]interface{}{
-							"type":        "string",
-							"description": "Caminho do arquivo",
-						},
-					},
-					"required": []string{"owner", "repo", "path"},
+		})
+	}
+
+	text := s.renderList(fmt.Sprintf("Repositórios para %q (%d)", q, len(repos)), limitItems(items, params.Arguments))
+	return s.outputResult(msg.ID, params.Arguments, text, repos[:limitCount(len(repos), params.Arguments)])
+}
+
+func (s *MCPServer) handleSearchCode(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	q, _ := params.Arguments["query"].(string)
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "query é obrigatório")
+	}
+	search, scope := q, "GitHub"
+	if repoArg, _ := params.Arguments["repo"].(string); strings.TrimSpace(repoArg) != "" {
+		owner, repo, err := repoArgs(params.Arguments)
+		if err != nil {
+			return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+		}
+		scope = owner + "/" + repo
+		search += " repo:" + scope
+	}
+
+	result, err := s.github.SearchCode(ctx, search)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	items := make([]listItem, 0, len(result.Items))
+	for _, item := range result.Items {
+		fields := []field{{"Repositório", item.Repository.FullName}}
+		for _, match := range item.TextMatches {
+			fields = append(fields, field{"Trecho", strings.Join(strings.Fields(match.Fragment), " ")})
+		}
+		items = append(items, listItem{Title: item.Path, URL: item.HTMLURL, Fields: fields})
+	}
+
+	header := fmt.Sprintf("Código encontrado para %q em %s (%d de %d)", q, scope, len(result.Items), result.TotalCount)
+	text := s.renderList(header, limitItems(items, params.Arguments))
+	if result.IncompleteResults {
+		text += "\nAviso: a busca expirou no GitHub e os resultados podem estar incompletos.\n"
+	}
+	result.Items = result.Items[:limitCount(len(result.Items), params.Arguments)]
+	return s.outputResult(msg.ID, params.Arguments, text, result)
+}
+
+// changelogGroups define a ordem e o título das seções do changelog para os
+// prefixos conventional commit reconhecidos.
+var changelogGroups = []struct {
+	Prefix string
+	Title  string
+}{
+	{"feat", "Funcionalidades"},
+	{"fix", "Correções"},
+	{"perf", "Desempenho"},
+	{"refactor", "Refatorações"},
+	{"docs", "Documentação"},
+	{"test", "Testes"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"chore", "Manutenção"},
+}
+
+// conventionalPrefix separa "tipo(escopo)!: descrição" em tipo e descrição.
+// Retorna tipo vazio quando o assunto não segue o formato.
+func conventionalPrefix(subject string) (string, string) {
+	colon := strings.Index(subject, ": ")
+	if colon <= 0 {
+		return "", subject
+	}
+	kind := strings.TrimSuffix(subject[:colon], "!")
+	if open := strings.Index(kind, "("); open > 0 && strings.HasSuffix(kind, ")") {
+		kind = kind[:open]
+	}
+	kind = strings.ToLower(kind)
+	for _, group := range changelogGroups {
+		if group.Prefix == kind {
+			return kind, strings.TrimSpace(subject[colon+2:])
+		}
+	}
+	return "", subject
+}
+
+func (s *MCPServer) handleChangelog(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	from, _ := params.Arguments["from"].(string)
+	to, _ := params.Arguments["to"].(string)
+	if from == "" || to == "" {
+		return errorResult(msg.ID, -32602, "Invalid params", "from e to são obrigatórios")
+	}
+	includeMerges := boolArg(params.Arguments, "include_merges")
+
+	var shas [2]string
+	for i, ref := range []string{from, to} {
+		sha, err := s.github.GetCommitSHA(ctx, owner, repo, ref)
+		if err != nil {
+			return githubErrorResult(msg.ID, fmt.Errorf("não foi possível resolver %q: %w", ref, err))
+		}
+		shas[i] = sha
+	}
+
+	comparison, err := s.github.CompareCommits(ctx, owner, repo, shas[0], shas[1])
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	grouped := map[string][]listItem{}
+	var chronological []listItem
+	hasPrefixes := false
+	for _, commit := range comparison.Commits {
+		if commit.IsMerge() && !includeMerges {
+			continue
+		}
+		subject := strings.SplitN(s.userText(commit.Message, params.Arguments), "\n", 2)[0]
+		kind, description := conventionalPrefix(subject)
+		if kind != "" {
+			hasPrefixes = true
+		}
+		item := listItem{Title: fmt.Sprintf("%s (%s)", description, shortSHA(commit.SHA)), URL: commit.HTMLURL}
+		grouped[kind] = append(grouped[kind], item)
+		chronological = append(chronological, listItem{Title: fmt.Sprintf("%s (%s)", subject, shortSHA(commit.SHA)), URL: commit.HTMLURL})
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Changelog de %s/%s: %s...%s (%d commits)\n\n", owner, repo, from, to, len(chronological)))
+	if hasPrefixes {
+		for _, group := range changelogGroups {
+			if items := grouped[group.Prefix]; len(items) > 0 {
+				result.WriteString(s.renderList(group.Title, items))
+				result.WriteString("\n")
+			}
+		}
+		if items := grouped[""]; len(items) > 0 {
+			result.WriteString(s.renderList("Outros", items))
+		}
+	} else {
+		result.WriteString(s.renderList("Commits", chronological))
+	}
+
+	if comparison.TotalCommits > len(comparison.Commits) {
+		result.WriteString(fmt.Sprintf("\nAviso: a comparação tem %d commits, mas o GitHub retornou apenas %d.\n", comparison.TotalCommits, len(comparison.Commits)))
+	}
+
+	return textResult(msg.ID, result.String())
+}
+
+func (s *MCPServer) handleWatchRepo(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+	ignored := boolArg(params.Arguments, "ignored")
+
+	subscription, err := s.github.SetSubscription(ctx, owner, repo, !ignored, ignored)
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.subscriptionResult(msg.ID, params.Arguments, owner, repo, subscription)
+}
+
+func (s *MCPServer) handleUnwatchRepo(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
+	owner, repo, err := repoArgs(params.Arguments)
+	if err != nil {
+		return errorResult(msg.ID, -32602, "Invalid params", err.Error())
+	}
+
+	err = s.github.DeleteSubscription(ctx, owner, repo)
+	var subscription *GitHubSubscription
+	if err == nil {
+		subscription, err = s.github.GetSubscription(ctx, owner, repo)
+	}
+	if err != nil {
+		return githubErrorResult(msg.ID, err)
+	}
+
+	return s.subscriptionResult(msg.ID, params.Arguments, owner, repo, subscription)
+}
+
+// subscriptionResult responde watch_repo e unwatch_repo. Sem inscrição,
+// structuredContent vai com subscribed e ignored falsos.
+func (s *MCPServer) subscriptionResult(id interface{}, args map[string]interface{}, owner, repo string, subscription *GitHubSubscription) MCPMessage {
+	data := GitHubSubscription{}
+	if subscription != nil {
+		data = *subscription
+	}
+	return s.outputResult(id, args, s.renderSubscription(owner, repo, subscription), data)
+}
+
+func (s *MCPServer) renderSubscription(owner, repo string, subscription *GitHubSubscription) string {
+	header := fmt.Sprintf("Inscrição em %s/%s", owner, repo)
+	if subscription == nil {
+		return s.renderDetails(header, []field{{"Estado", "não acompanhando"}})
+	}
+
+	state := "acompanhando"
+	if subscription.Ignored {
+		state = "silenciado"
+	}
+	return s.renderDetails(header, []field{
+		{"Estado", state},
+		{"Desde", subscription.CreatedAt},
+	})
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// formatLanguageShares ordena as linguagens por bytes e as formata com a
+// porcentagem de cada uma, ex.: "Go 80.0%, Shell 20.0%".
+func formatLanguageShares(languages map[string]int) string {
+	names := make([]string, 0, len(languages))
+	total := 0
+	for name, bytes := range languages {
+		names = append(names, name)
+		total += bytes
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if languages[names[i]] != languages[names[j]] {
+			return languages[names[i]] > languages[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	shares := make([]string, 0, len(names))
+	for _, name := range names {
+		share := 0.0
+		if total > 0 {
+			share = float64(languages[name]) * 100 / float64(total)
+		}
+		shares = append(shares, fmt.Sprintf("%s %.1f%%", name, share))
+	}
+	return strings.Join(shares, ", ")
+}
+
+func userItems(users []GitHubUser) []listItem {
+	items := make([]listItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, listItem{Title: user.Login, URL: user.HTMLURL})
+	}
+	return items
+}
+
+// Sanitização de conteúdo de usuários
+//
+// Títulos, mensagens de commit e corpos vêm de terceiros e podem esconder
+// instruções para o modelo em HTML ou comentários. Com SANITIZE_BODIES=true
+// esse texto passa por sanitizeBody antes de entrar na resposta.
+
+var (
+	htmlCommentPattern     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTagPattern         = regexp.MustCompile(`</?[a-zA-Z][^<>]*>`)
+	markdownCommentPattern = regexp.MustCompile(`(?m)^[ \t]*\[[^\]]*\]:[ \t]*#.*$`)
+	invisibleChars         = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "")
+)
+
+// sanitizeBody remove comentários HTML e markdown (onde instruções ficam
+// invisíveis na página renderizada), tags HTML e caracteres de largura zero.
+// Comentários removidos são sinalizados para que a remoção fique visível.
+func sanitizeBody(text string) string {
+	text = htmlCommentPattern.ReplaceAllString(text, "[comentário oculto removido]")
+	text = markdownCommentPattern.ReplaceAllString(text, "[comentário oculto removido]")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	return invisibleChars.Replace(text)
+}
+
+// userText aplica sanitizeBody a texto escrito por usuários quando o modo de
+// sanitização está ativo e a chamada não pediu raw.
+func (s *MCPServer) userText(text string, args map[string]interface{}) string {
+	if !s.sanitize || boolArg(args, "raw") {
+		return text
+	}
+	return sanitizeBody(text)
+}
+
+// Formatação de respostas
+//
+// Os handlers montam os dados em fields/listItems e os helpers abaixo
+// cuidam da renderização, em texto simples (padrão) ou markdown.
+
+const (
+	formatPlain    = "plain"
+	formatMarkdown = "markdown"
+)
+
+type field struct {
+	Label string
+	Value string
+}
+
+type listItem struct {
+	Title  string
+	URL    string
+	Fields []field
+}
+
+func parseOutputFormat(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", formatPlain:
+		return formatPlain, nil
+	case formatMarkdown:
+		return formatMarkdown, nil
+	default:
+		return "", fmt.Errorf("OUTPUT_FORMAT inválido: %q (use plain ou markdown)", value)
+	}
+}
+
+var markdownLinkEscaper = strings.NewReplacer("[", "\\[", "]", "\\]")
+
+func markdownLink(title, url string) string {
+	if url == "" {
+		return title
+	}
+	return fmt.Sprintf("[%s](%s)", markdownLinkEscaper.Replace(title), url)
+}
+
+// renderList renderiza uma lista de itens com cabeçalho. No modo plain a URL
+// vira um campo do item; no modo markdown o título vira um link.
+func (s *MCPServer) renderList(header string, items []listItem) string {
+	var result strings.Builder
+
+	if s.format == formatMarkdown {
+		result.WriteString(fmt.Sprintf("### %s\n\n", header))
+		for _, item := range items {
+			result.WriteString(fmt.Sprintf("- %s\n", markdownLink(item.Title, item.URL)))
+			for _, f := range item.Fields {
+				result.WriteString(fmt.Sprintf("  - **%s:** %s\n", f.Label, f.Value))
+			}
+		}
+		return result.String()
+	}
+
+	result.WriteString(fmt.Sprintf("%s:\n\n", header))
+	for _, item := range items {
+		result.WriteString(fmt.Sprintf("- %s\n", item.Title))
+		for _, f := range item.Fields {
+			result.WriteString(fmt.Sprintf("  %s: %s\n", f.Label, f.Value))
+		}
+		if item.URL != "" {
+			result.WriteString(fmt.Sprintf("  URL: %s\n", item.URL))
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// renderDetails renderiza um único recurso como pares rótulo/valor. O
+// cabeçalho é opcional.
+func (s *MCPServer) renderDetails(header string, fields []field) string {
+	lines := make([]string, 0, len(fields))
+
+	if s.format == formatMarkdown {
+		for _, f := range fields {
+			value := f.Value
+			if f.Label == "URL" {
+				value = markdownLink(f.Value, f.Value)
+			}
+			lines = append(lines, fmt.Sprintf("- **%s:** %s", f.Label, value))
+		}
+		if header == "" {
+			return strings.Join(lines, "\n") + "\n"
+		}
+		return fmt.Sprintf("### %s\n\n%s\n", header, strings.Join(lines, "\n"))
+	}
+
+	for _, f := range fields {
+		lines = append(lines, fmt.Sprintf("%s: %s", f.Label, f.Value))
+	}
+	if header == "" {
+		return strings.Join(lines, "\n")
+	}
+	return fmt.Sprintf("%s:\n\n%s\n", header, strings.Join(lines, "\n"))
+}
+
+// renderBlock renderiza um bloco de texto livre (conteúdo de arquivo, diff...).
+func (s *MCPServer) renderBlock(label, body string) string {
+	if s.format == formatMarkdown {
+		return fmt.Sprintf("\n**%s:**\n\n```\n%s\n```\n", label, strings.TrimRight(body, "\n"))
+	}
+	return fmt.Sprintf("\n%s:\n%s", label, body)
+}
+
+// textResult monta o resultado de uma ferramenta com um único bloco de texto,
+// mesmo quando text é vazio, para que content nunca chegue vazio ao cliente.
+func textResult(id interface{}, text string) MCPMessage {
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result: CallToolResult{
+			Content: []map[string]interface{}{
+				{
+					"type": "text",
+					"text": text,
 				},
 			},
 		},
 	}
 }
 
-func (s *MCPServer) HandleMessage(ctx context.Context, msg MCPMessage) MCPMessage {
-	switch msg.Method {
-	case "initialize":
-		return s.handleInitialize(msg)
-	case "tools/list":
-		return s.handleToolsList(msg)
-	case "tools/call":
-		return s.handleToolsCall(ctx, msg)
-	case "ping":
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Result:  map[string]interface{}{"status": "pong"},
+// structuredResult é como textResult, mas também devolve data em
+// structuredContent; data deve obedecer ao OutputSchema da ferramenta.
+func structuredResult(id interface{}, text string, data interface{}) MCPMessage {
+	msg := textResult(id, text)
+	result := msg.Result.(CallToolResult)
+	result.StructuredContent = data
+	msg.Result = result
+	return msg
+}
+
+// configureFromEnv aplica ao servidor as configurações opcionais lidas das
+// variáveis de ambiente.
+func configureFromEnv(server *MCPServer) error {
+	format, err := parseOutputFormat(os.Getenv("OUTPUT_FORMAT"))
+	if err != nil {
+		return err
+	}
+	server.format = format
+
+	perPage, err := parsePerPage(os.Getenv("GITHUB_PER_PAGE"))
+	if err != nil {
+		return err
+	}
+	server.defaultClient.perPage = perPage
+
+	if value := os.Getenv("GITHUB_STATS_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return fmt.Errorf("GITHUB_STATS_RETRIES inválido: %q", value)
+		}
+		server.defaultClient.statsRetries = retries
+	}
+
+	if value := os.Getenv("GITHUB_STATS_RETRY_DELAY"); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return fmt.Errorf("GITHUB_STATS_RETRY_DELAY inválido: %q (ex.: 2s, 500ms)", value)
+		}
+		server.defaultClient.statsRetryDelay = delay
+	}
+
+	if value := os.Getenv("GITHUB_SECONDARY_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return fmt.Errorf("GITHUB_SECONDARY_RETRIES inválido: %q", value)
+		}
+		server.defaultClient.secondaryRetries = retries
+	}
+
+	if value := os.Getenv("GITHUB_SECONDARY_MAX_DELAY"); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return fmt.Errorf("GITHUB_SECONDARY_MAX_DELAY inválido: %q (ex.: 5m, 90s)", value)
+		}
+		server.defaultClient.secondaryMaxDelay = delay
+	}
+
+	if value := os.Getenv("GITHUB_NETWORK_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return fmt.Errorf("GITHUB_NETWORK_RETRIES inválido: %q", value)
+		}
+		server.defaultClient.networkRetries = retries
+	}
+
+	if value := os.Getenv("GITHUB_NETWORK_RETRY_DELAY"); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return fmt.Errorf("GITHUB_NETWORK_RETRY_DELAY inválido: %q (ex.: 1s, 500ms)", value)
+		}
+		server.defaultClient.networkRetryDelay = delay
+	}
+
+	if value := os.Getenv("GITHUB_SERVER_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return fmt.Errorf("GITHUB_SERVER_RETRIES inválido: %q", value)
+		}
+		server.defaultClient.maxRetries = retries
+	}
+
+	if value := os.Getenv("GITHUB_RETRY_BUDGET"); value != "" {
+		budget, err := strconv.Atoi(value)
+		if err != nil || budget < 0 {
+			return fmt.Errorf("GITHUB_RETRY_BUDGET inválido: %q", value)
+		}
+		server.defaultClient.retries = newRetryBudget(budget)
+	}
+
+	if value := os.Getenv("ARTIFACT_DIR"); value != "" {
+		dir, err := filepath.Abs(value)
+		if err != nil {
+			return fmt.Errorf("ARTIFACT_DIR inválido: %q: %v", value, err)
+		}
+		server.artifactDir = dir
+	}
+
+	if value := os.Getenv("IDLE_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("IDLE_TIMEOUT inválido: %q (ex.: 10m, 0 para desativar)", value)
+		}
+		server.idleTimeout = timeout
+	}
+
+	if value := os.Getenv("MCP_MAX_RESPONSE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return fmt.Errorf("MCP_MAX_RESPONSE_SIZE inválido: %q (bytes, 0 para desativar)", value)
+		}
+		server.maxResponseSize = size
+	}
+
+	switch sink := strings.TrimSpace(os.Getenv("AUDIT_FILE")); sink {
+	case "":
+	case "stderr", "-":
+		server.audit = log.New(os.Stderr, "audit: ", 0)
+	default:
+		file, err := os.OpenFile(sink, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("AUDIT_FILE inválido: %w", err)
+		}
+		server.audit = log.New(file, "", 0)
+	}
+
+	if host := strings.ToLower(strings.TrimSpace(os.Getenv("GITHUB_ENTERPRISE_HOST"))); host != "" {
+		server.defaultClient.allowedHosts = append(server.defaultClient.allowedHosts, host)
+	}
+	server.defaultClient.allowInsecure = os.Getenv("ALLOW_INSECURE") == "true"
+	server.defaultClient.trace = os.Getenv("GITHUB_TRACE") == "true"
+	server.sanitize = os.Getenv("SANITIZE_BODIES") == "true"
+	server.readOnly = os.Getenv("READ_ONLY") == "true"
+	server.toolPrefix = strings.TrimSpace(os.Getenv("MCP_TOOL_PREFIX"))
+
+	// Saída indentada é só para depuração: quebra o protocolo de uma mensagem
+	// JSON por linha, por isso exige exatamente MCP_PRETTY=true.
+	server.pretty = os.Getenv("MCP_PRETTY") == "true"
+	if server.pretty {
+		log.Println("AVISO: MCP_PRETTY=true ativo; respostas indentadas não são compatíveis com clientes MCP")
+	}
+
+	return server.defaultClient.checkBaseURL()
+}
+
+func parsePerPage(value string) (int, error) {
+	if value == "" {
+		return defaultPerPage, nil
+	}
+	perPage, err := strconv.Atoi(value)
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return 0, fmt.Errorf("GITHUB_PER_PAGE inválido: %q (use um valor entre 1 e %d)", value, maxPerPage)
+	}
+	return perPage, nil
+}
+
+func errorResult(id interface{}, code int, message, data string) MCPMessage {
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &MCPError{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+	}
+}
+
+// Códigos de erro para falhas da API do GitHub, na faixa que o JSON-RPC
+// reserva para erros do servidor (-32000 a -32099).
+const (
+	codeGitHubAuth     = -32001
+	codeGitHubNotFound = -32004
+)
+
+// githubErrorResult converte o erro de uma chamada ao GitHub em resposta, para
+// que o cliente distinga um recurso inexistente de uma falha do servidor: 404
+// vira "Not found", 401 e 403 "Authentication error" e o resto (5xx, rede,
+// rate limit) continua -32603 "Internal error". Data leva err.Error(), que
+// inclui a mensagem do corpo de erro do GitHub.
+func githubErrorResult(id interface{}, err error) MCPMessage {
+	var apiErr *GitHubAPIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound:
+			return errorResult(id, codeGitHubNotFound, "Not found", err.Error())
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return errorResult(id, codeGitHubAuth, "Authentication error", err.Error())
+		}
+	}
+	return errorResult(id, -32603, "Internal error", err.Error())
+}
+
+func (s *MCPServer) findTool(name string) (Tool, bool) {
+	for _, tool := range s.tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// arrayProp declara no InputSchema um argumento array cujos itens são do tipo
+// itemType ("string", "integer", ...).
+func arrayProp(description, itemType string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": itemType},
+		"description": description,
+	}
+}
+
+// objectProp declara no InputSchema um argumento objeto com as propriedades
+// e os campos obrigatórios informados.
+func objectProp(description string, properties map[string]interface{}, required ...string) map[string]interface{} {
+	prop := map[string]interface{}{
+		"type":        "object",
+		"properties":  properties,
+		"description": description,
+	}
+	if len(required) > 0 {
+		prop["required"] = required
+	}
+	return prop
+}
+
+// validateArguments confere os argumentos de uma chamada contra o InputSchema
+// da ferramenta: campos obrigatórios presentes, tipos compatíveis e valores
+// de enum, inclusive itens de arrays e objetos aninhados. Argumentos não declarados são ignorados.
+func validateArguments(schema map[string]interface{}, args map[string]interface{}) error {
+	return validateObject("", schema, args)
+}
+
+func validateObject(path string, schema map[string]interface{}, args map[string]interface{}) error {
+	required, _ := schema["required"].([]string)
+	for _, name := range required {
+		if v, ok := args[name]; !ok || v == nil {
+			return fmt.Errorf("argumento obrigatório ausente: %s", path+name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, value := range args {
+		prop, ok := properties[name].(map[string]interface{})
+		if !ok || value == nil {
+			continue
+		}
+		if err := validateValue(path+name, prop, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateValue checa value contra o tipo declarado em prop. Números e
+// booleanos também são aceitos como string, como fazem intArg e boolArg.
+func validateValue(path string, prop map[string]interface{}, value interface{}) error {
+	// Uniões de tipos (ex.: ["string", "integer"]) aceitam qualquer um deles.
+	if types, ok := prop["type"].([]string); ok {
+		var err error
+		for _, typ := range types {
+			alt := map[string]interface{}{"type": typ}
+			if err = validateValue(path, alt, value); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+
+	typ, _ := prop["type"].(string)
+	switch typ {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s deve ser string", path)
+		}
+		if enum, ok := prop["enum"].([]string); ok {
+			for _, allowed := range enum {
+				if str == allowed {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s deve ser um de: %s", path, strings.Join(enum, ", "))
+		}
+	case "integer":
+		if _, ok := intArg(map[string]interface{}{"v": value}, "v"); !ok {
+			return fmt.Errorf("%s deve ser um número inteiro", path)
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+		case string:
+			if !strings.EqualFold(v, "true") && !strings.EqualFold(v, "false") {
+				return fmt.Errorf("%s deve ser booleano", path)
+			}
+		default:
+			return fmt.Errorf("%s deve ser booleano", path)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s deve ser um array", path)
+		}
+		itemSchema, _ := prop["items"].(map[string]interface{})
+		if itemSchema == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := validateValue(fmt.Sprintf("%s[%d]", path, i), itemSchema, item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s deve ser um objeto", path)
+		}
+		return validateObject(path+".", prop, obj)
+	}
+	return nil
+}
+
+// stringSliceArg converte um argumento array do JSON ([]interface{}) para
+// []string, ignorando itens vazios ou que não sejam strings.
+func stringSliceArg(args map[string]interface{}, name string) []string {
+	raw, _ := args[name].([]interface{})
+	values := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if v, ok := item.(string); ok && strings.TrimSpace(v) != "" {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
+
+// containsString diz se value está em values.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// repoArgs extrai owner e repo dos argumentos de uma ferramenta. Quando os
+// dois vêm separados são usados como estão; caso contrário repo pode vir como
+// "owner/repo", "https://github.com/owner/repo" ou
+// "git@github.com:owner/repo.git".
+func repoArgs(args map[string]interface{}) (string, string, error) {
+	owner, _ := args["owner"].(string)
+	repo, _ := args["repo"].(string)
+	owner = strings.TrimSpace(owner)
+	repo = strings.TrimSpace(repo)
+
+	if owner != "" && repo != "" {
+		return owner, repo, nil
+	}
+	if repo == "" {
+		return "", "", fmt.Errorf("repo é obrigatório")
+	}
+
+	owner, name, ok := parseRepoRef(repo)
+	if !ok {
+		return "", "", fmt.Errorf("repo inválido: %q (use owner/repo, uma URL do GitHub ou informe owner separadamente)", repo)
+	}
+	return owner, name, nil
+}
+
+func parseRepoRef(ref string) (string, string, bool) {
+	path := ref
+	switch {
+	case strings.HasPrefix(ref, "git@"):
+		i := strings.Index(ref, ":")
+		if i < 0 {
+			return "", "", false
 		}
+		path = ref[i+1:]
+	case strings.Contains(ref, "://"):
+		u, err := url.Parse(ref)
+		if err != nil {
+			return "", "", false
+		}
+		path = u.Path
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	// URLs de navegação (ex.: .../tree/main) trazem segmentos extras.
+	if len(parts) > 2 && !strings.Contains(ref, "://") {
+		return "", "", false
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// scalarArg lê um argumento que pode chegar como string ou número e o devolve
+// como string sem espaços.
+func scalarArg(args map[string]interface{}, name string) string {
+	switch v := args[name].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
 	default:
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32601,
-				Message: "Method not found",
-			},
+		return ""
+	}
+}
+
+// boolArg lê um argumento booleano; aceita true/false do JSON ou a string
+// "true".
+func boolArg(args map[string]interface{}, name string) bool {
+	switch v := args[name].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(strings.TrimSpace(v), "true")
+	default:
+		return false
+	}
+}
+
+// intArg lê um argumento numérico inteiro. Números JSON chegam como float64;
+// strings numéricas também são aceitas.
+func intArg(args map[string]interface{}, name string) (int, bool) {
+	switch v := args[name].(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
 		}
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	default:
+		return 0, false
 	}
 }
 
-func (s *MCPServer) handleInitialize(msg MCPMessage) MCPMessage {
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: InitializeResult{
-			ProtocolVersion: "2024-11-05",
-			Capabilities: map[string]interface{}{
-				"tools": map[string]interface{}{
-					"listChanged": true,
-				},
-			},
-			ServerInfo: map[string]interface{}{
-				"name":    "GitHub MCP Server",
-				"version": "1.0.0",
-			},
-		},
+// limitProperty é o argumento opcional limit, comum às ferramentas de
+// listagem.
+var limitProperty = map[string]interface{}{
+	"type":        "integer",
+	"description": "Máximo de itens devolvidos (aplicado depois da busca; com max_pages, vale o que for atingido primeiro)",
+}
+
+// maxToolTimeoutSeconds é o maior timeout_seconds aceito em uma chamada.
+const maxToolTimeoutSeconds = 300
+
+// timeoutProperty é o argumento opcional timeout_seconds, aceito por todas as
+// ferramentas. Cada requisição ao GitHub continua limitada ao timeout do
+// cliente HTTP; timeout_seconds limita a chamada inteira (paginação e novas
+// tentativas incluídas).
+var timeoutProperty = map[string]interface{}{
+	"type":        "integer",
+	"description": "Tempo máximo da chamada em segundos, de 1 a 300 (padrão: sem limite além do timeout de cada requisição)",
+}
+
+// Valores do argumento output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// outputProperty é o argumento opcional output das ferramentas que, além do
+// texto formatado, sabem devolver o resultado em JSON.
+var outputProperty = map[string]interface{}{
+	"type":        "string",
+	"enum":        []string{outputText, outputJSON},
+	"description": "Formato da resposta: text (padrão, legível) ou json (o resultado da API como JSON, com todos os campos)",
+}
+
+// jsonOutput diz se a chamada pediu output: "json".
+func jsonOutput(args map[string]interface{}) bool {
+	output, _ := args["output"].(string)
+	return output == outputJSON
+}
+
+// outputResult monta a resposta das ferramentas que têm o resultado tipado.
+// data vai em structuredContent e, com output: "json", também substitui o
+// texto formatado. Com SANITIZE_BODIES (sem raw) structuredContent fica de
+// fora, pois traria o texto dos usuários sem sanitização.
+func (s *MCPServer) outputResult(id interface{}, args map[string]interface{}, text string, data interface{}) MCPMessage {
+	if jsonOutput(args) {
+		body, err := json.Marshal(data)
+		if err != nil {
+			return errorResult(id, -32603, "Internal error", err.Error())
+		}
+		text = string(body)
+	}
+	msg := textResult(id, text)
+	if !s.sanitize || boolArg(args, "raw") {
+		msg = structuredResult(id, text, structuredData(data))
 	}
+	if jsonOutput(args) {
+		result := msg.Result.(CallToolResult)
+		result.jsonText = true
+		msg.Result = result
+	}
+	return msg
 }
 
-func (s *MCPServer) handleToolsList(msg MCPMessage) MCPMessage {
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: map[string]interface{}{
-			"tools": s.tools,
-		},
+// structuredData adapta data para structuredContent, que precisa ser um
+// objeto: listas vão dentro de {"items": [...]}.
+func structuredData(data interface{}) interface{} {
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			data = []interface{}{}
+		}
+		return map[string]interface{}{"items": data}
 	}
+	return data
 }
 
-func (s *MCPServer) handleToolsCall(ctx context.Context, msg MCPMessage) MCPMessage {
-	var params CallToolParams
-	
-	// Converter params para JSON e depois fazer unmarshal
-	paramsBytes, err := json.Marshal(msg.Params)
-	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32602,
-				Message: "Invalid params",
-				Data:    err.Error(),
+// outputSchemaOf descreve em JSON Schema o structuredContent que
+// outputResult monta para dados do tipo de v: os campos seguem as tags json e
+// listas vão em {"items": [...]}, como em structuredData.
+func outputSchemaOf(v interface{}) map[string]interface{} {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Slice {
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"items": map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), nil)},
 			},
+			"required": []string{"items"},
 		}
 	}
-	
-	if err := json.Unmarshal(paramsBytes, &params); err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32602,
-				Message: "Invalid params",
-				Data:    err.Error(),
-			},
+	return typeSchema(t, nil)
+}
+
+// typeSchema descreve o tipo t. Ponteiros e slices aceitam null, que é como
+// encoding/json os serializa quando nil; seen corta a recursão de tipos que
+// referenciam a si mesmos (GitHubRepo.Parent).
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := typeSchema(t.Elem(), seen)
+		if kind, ok := schema["type"].(string); ok {
+			schema["type"] = []string{kind, "null"}
+		}
+		return schema
+	case reflect.Slice:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		inner := map[reflect.Type]bool{t: true}
+		for k := range seen {
+			inner[k] = true
+		}
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = typeSchema(f.Type, inner)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	}
+	return map[string]interface{}{}
+}
+
+// pageProperty, perPageProperty e allPagesProperty são os argumentos
+// opcionais de paginação das listagens simples (get_repos, get_issues,
+// get_pull_requests e get_commits).
+var pageProperty = map[string]interface{}{
+	"type":        "integer",
+	"description": "Página a buscar, a partir de 1 (padrão: 1; ignorado com all)",
+}
+
+var perPageProperty = map[string]interface{}{
+	"type":        "integer",
+	"description": "Itens por página, de 1 a 100 (padrão: GITHUB_PER_PAGE)",
+}
+
+var allPagesProperty = map[string]interface{}{
+	"type":        "boolean",
+	"description": "Buscar todas as páginas seguindo o cabeçalho Link e concatenar os resultados",
+}
+
+// limitArg devolve o argumento limit, ou 0 quando ausente (sem limite). A
+// validação (inteiro positivo) é feita em handleToolsCall.
+func limitArg(args map[string]interface{}) int {
+	limit, _ := intArg(args, "limit")
+	return limit
+}
+
+// pageOptionsArg lê os argumentos page, per_page e all das ferramentas de
+// listagem.
+func pageOptionsArg(args map[string]interface{}) (PageOptions, error) {
+	var opts PageOptions
+	if _, present := args["page"]; present {
+		page, ok := intArg(args, "page")
+		if !ok || page < 1 {
+			return opts, fmt.Errorf("page deve ser um inteiro positivo")
+		}
+		opts.Page = page
+	}
+	if _, present := args["per_page"]; present {
+		perPage, ok := intArg(args, "per_page")
+		if !ok || perPage < 1 || perPage > maxPerPage {
+			return opts, fmt.Errorf("per_page deve ser um inteiro entre 1 e %d", maxPerPage)
+		}
+		opts.PerPage = perPage
+	}
+	opts.All = boolArg(args, "all")
+	return opts, nil
+}
+
+// limitCount devolve quantos de n itens cabem no argumento limit.
+func limitCount(n int, args map[string]interface{}) int {
+	if limit := limitArg(args); limit > 0 && n > limit {
+		return limit
+	}
+	return n
+}
+
+// limitItems corta items nos primeiros limit itens, se houver limit.
+func limitItems(items []listItem, args map[string]interface{}) []listItem {
+	if limit := limitArg(args); limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
+// maxContentSize limita o tamanho de textos grandes (diffs, arquivos)
+// devolvidos em uma resposta.
+const maxContentSize = 100 * 1024
+
+// truncateText corta text em no máximo limit bytes sem quebrar caracteres
+// UTF-8 e informa se houve corte.
+func truncateText(text string, limit int) (string, bool) {
+	if len(text) <= limit {
+		return text, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut], true
+}
+
+// defaultMaxResponseSize é o limite padrão, em bytes, do texto e do
+// structuredContent somados de uma resposta ou de um lote de respostas
+// JSON-RPC.
+const defaultMaxResponseSize = 1024 * 1024
+
+// limitResponseSize aplica s.maxResponseSize ao texto de todos os blocos de
+// conteúdo das respostas, que são escritas juntas, somado ao
+// structuredContent serializado. Quando o total excede o limite,
+// structuredContent é removido e, se ainda não couber, cada bloco é truncado na proporção do seu tamanho e recebe um aviso,
+// para que um resultado grande não apague os demais do lote. O JSON de
+// output: "json" nunca é cortado no meio: de uma lista ficam só os itens
+// inteiros que cabem, e um objeto que não cabe vira um erro.
+func (s *MCPServer) limitResponseSize(responses []MCPMessage) {
+	if s.maxResponseSize <= 0 {
+		return
+	}
+
+	type textBlock struct {
+		response int
+		block    map[string]interface{}
+		json     bool
+	}
+	var blocks []textBlock
+	total, structured := 0, 0
+	for i, response := range responses {
+		result, ok := response.Result.(CallToolResult)
+		if !ok {
+			continue
+		}
+		for _, block := range result.Content {
+			if text, ok := block["text"].(string); ok {
+				blocks = append(blocks, textBlock{i, block, result.jsonText})
+				total += len(text)
+			}
+		}
+		if result.StructuredContent != nil {
+			body, _ := json.Marshal(result.StructuredContent)
+			structured += len(body)
+		}
+	}
+	if total+structured <= s.maxResponseSize {
+		return
+	}
+
+	// structuredContent repete os dados do texto e é o primeiro a sair; o
+	// texto só é cortado se ainda não couber.
+	if structured > 0 {
+		for i, response := range responses {
+			if result, ok := response.Result.(CallToolResult); ok && result.StructuredContent != nil {
+				result.StructuredContent = nil
+				responses[i].Result = result
+			}
+		}
+		if total <= s.maxResponseSize {
+			return
+		}
+	}
+
+	// Os avisos de truncamento também contam no limite: reserva-se para cada
+	// bloco o maior aviso possível e só o restante é dividido entre os
+	// textos. O aviso de uma lista JSON, contado em itens, nunca é maior.
+	available := s.maxResponseSize
+	for _, b := range blocks {
+		text := b.block["text"].(string)
+		available -= len(truncationNotice(len(text), len(text), s.maxResponseSize))
+	}
+	if available < 0 {
+		available = 0
+	}
+
+	for _, b := range blocks {
+		text := b.block["text"].(string)
+		share := int(int64(len(text)) * int64(available) / int64(total))
+		if !b.json {
+			if truncated, cut := truncateText(text, share); cut {
+				b.block["text"] = truncated + truncationNotice(len(truncated), len(text), s.maxResponseSize)
+			}
+			continue
+		}
+		if len(text) <= share {
+			continue
+		}
+		trimmed, kept, count, ok := trimJSONList(text, share)
+		if !ok {
+			responses[b.response] = errorResult(responses[b.response].ID, -32603, "Internal error",
+				fmt.Sprintf("resultado JSON de %d bytes excede o limite de %d bytes da resposta (MCP_MAX_RESPONSE_SIZE); reduza o resultado com limit ou paginação", len(text), s.maxResponseSize))
+			continue
+		}
+		// O aviso vai em um bloco à parte para que o texto continue sendo
+		// JSON válido.
+		b.block["text"] = trimmed
+		result := responses[b.response].Result.(CallToolResult)
+		result.Content = append(result.Content, map[string]interface{}{
+			"type": "text",
+			"text": jsonTruncationNotice(kept, count, s.maxResponseSize),
+		})
+		responses[b.response].Result = result
+	}
+}
+
+// trimJSONList reduz a lista JSON text aos primeiros itens que cabem em limit
+// bytes e devolve a lista resultante, quantos itens ficaram e quantos havia.
+// ok é false quando text não é uma lista JSON.
+func trimJSONList(text string, limit int) (trimmed string, kept, count int, ok bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(text), &items); err != nil || items == nil {
+		return "", 0, 0, false
+	}
+	size := len("[]")
+	for kept < len(items) {
+		next := size + len(items[kept])
+		if kept > 0 {
+			next++ // vírgula
+		}
+		if next > limit {
+			break
+		}
+		size = next
+		kept++
+	}
+	body, err := json.Marshal(items[:kept])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return string(body), kept, len(items), true
+}
+
+// truncationNotice é o aviso anexado a um bloco cortado por limitResponseSize.
+func truncationNotice(kept, size, limit int) string {
+	return fmt.Sprintf("\n[conteúdo truncado em %d de %d bytes: limite de %d bytes da resposta atingido]\n", kept, size, limit)
+}
+
+// jsonTruncationNotice é o aviso, em um bloco próprio, de uma lista JSON
+// reduzida por limitResponseSize.
+func jsonTruncationNotice(kept, count, limit int) string {
+	return fmt.Sprintf("[lista JSON reduzida a %d de %d itens: limite de %d bytes da resposta atingido]", kept, count, limit)
+}
+
+// writeResponse serializa v (uma resposta ou um lote) e o escreve como uma
+// mensagem de t.
+func (s *MCPServer) writeResponse(t Transport, v interface{}) error {
+	var responseJSON []byte
+	if s.pretty {
+		responseJSON, _ = json.MarshalIndent(v, "", "  ")
+	} else {
+		responseJSON, _ = json.Marshal(v)
+	}
+	return t.WriteMessage(responseJSON)
+}
+
+// notificationBuffer é quantas notificações podem aguardar a escrita; com o
+// buffer cheio, novas notificações são descartadas.
+const notificationBuffer = 64
+
+// Notify enfileira uma notificação JSON-RPC (sem id) para os clientes
+// conectados. Não bloqueia: se a fila estiver cheia a notificação é
+// descartada e o descarte é registrado no log.
+func (s *MCPServer) Notify(method string, params interface{}) {
+	select {
+	case s.notifications <- MCPMessage{JSONRPC: "2.0", Method: method, Params: params}:
+	default:
+		log.Printf("Fila de notificações cheia; %s descartada", method)
+	}
+}
+
+// Timeouts do servidor de webhooks, para que conexões lentas ou abandonadas
+// não fiquem presas indefinidamente.
+const (
+	webhookReadHeaderTimeout = 10 * time.Second
+	webhookReadTimeout       = time.Minute
+	webhookWriteTimeout      = 10 * time.Second
+)
+
+// maxWebhookPayload é o tamanho máximo de uma entrega de webhook aceito; o
+// GitHub limita os payloads a 25 MB.
+const maxWebhookPayload = 25 << 20
+
+// WebhookHandler recebe entregas de webhooks do GitHub, confere a assinatura
+// X-Hub-Signature-256 (HMAC-SHA256 do corpo com secret) e emite uma
+// notificação MCP por evento, como notifications/github/issue_opened.
+// Entregas sem assinatura válida são recusadas com 401.
+func (s *MCPServer) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayload+1))
+		if err != nil {
+			http.Error(w, "erro ao ler o corpo", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxWebhookPayload {
+			http.Error(w, "payload grande demais", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !validWebhookSignature(secret, r.Header.Get("X-Hub-Signature-256"), body) {
+			log.Printf("Webhook recusado: assinatura inválida (entrega %s)", r.Header.Get("X-GitHub-Delivery"))
+			http.Error(w, "assinatura inválida", http.StatusUnauthorized)
+			return
+		}
+
+		event := r.Header.Get("X-GitHub-Event")
+		if event == "" {
+			http.Error(w, "X-GitHub-Event ausente", http.StatusBadRequest)
+			return
+		}
+		// ping só confirma a configuração do webhook; não vira notificação.
+		if event == "ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var payload struct {
+			Action     string `json:"action"`
+			Repository *struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+			Sender *struct {
+				Login string `json:"login"`
+			} `json:"sender"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "payload JSON inválido", http.StatusBadRequest)
+			return
+		}
+
+		params := map[string]interface{}{
+			"event":    event,
+			"delivery": r.Header.Get("X-GitHub-Delivery"),
+		}
+		// O payload original só acompanha a notificação se couber no limite
+		// de resposta; acima dele fica só o resumo (evento, ação, repositório
+		// e remetente) e o tamanho omitido.
+		if s.maxResponseSize <= 0 || len(body) <= s.maxResponseSize {
+			params["payload"] = json.RawMessage(body)
+		} else {
+			params["payload_omitted_bytes"] = len(body)
 		}
+		if payload.Action != "" {
+			params["action"] = payload.Action
+		}
+		if payload.Repository != nil {
+			params["repository"] = payload.Repository.FullName
+		}
+		if payload.Sender != nil {
+			params["sender"] = payload.Sender.Login
+		}
+		s.Notify(webhookNotificationMethod(event, payload.Action), params)
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// validWebhookSignature compara, em tempo constante, a assinatura enviada
+// pelo GitHub ("sha256=<hex>") com o HMAC-SHA256 de body.
+func validWebhookSignature(secret, signature string, body []byte) bool {
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return false
 	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
 
-	switch params.Name {
-	case "get_user":
-		return s.handleGetUser(ctx, msg, params)
-	case "get_repos":
-		return s.handleGetRepos(ctx, msg, params)
-	case "get_issues":
-		return s.handleGetIssues(ctx, msg, params)
-	case "get_pull_requests":
-		return s.handleGetPullRequests(ctx, msg, params)
-	case "get_commits":
-		return s.handleGetCommits(ctx, msg, params)
-	case "get_content":
-		return s.handleGetContent(ctx, msg, params)
-	default:
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32601,
-				Message: "Tool not found",
-			},
-		}
+// webhookNotificationMethod monta o método da notificação a partir do evento
+// e da ação: issues/opened vira notifications/github/issue_opened e push,
+// sem ação, notifications/github/push.
+func webhookNotificationMethod(event, action string) string {
+	if event == "issues" {
+		event = "issue"
+	}
+	if action == "" {
+		return "notifications/github/" + event
 	}
+	return "notifications/github/" + event + "_" + action
 }
 
-func (s *MCPServer) handleGetUser(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
-	username, _ := params.Arguments["username"].(string)
+// Enquadramentos aceitos por NewTransport (flag --framing ou MCP_FRAMING).
+const (
+	framingNewline       = "newline"
+	framingContentLength = "content-length"
+)
 
-	user, err := s.github.GetUser(ctx, username)
-	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
-				Data:    err.Error(),
-			},
-		}
-	}
+// maxFramedMessageSize limita o tamanho de uma mensagem recebida: o
+// Content-Length aceito, para que um cabeçalho inválido não reserve memória
+// sem limite, e o comprimento de uma linha no enquadramento padrão.
+const maxFramedMessageSize = 32 << 20
 
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: CallToolResult{
-			Content: []map[string]interface{}{
-				{
-					"type": "text",
-					"text": fmt.Sprintf("Usuário: %s\nNome: %s\nBio: %s\nLocalização: %s\nEmpresa: %s\nSeguidores: %d\nSeguindo: %d\nURL: %s",
-						user.Login, user.Name, user.Bio, user.Location, user.Company, user.Followers, user.Following, user.HTMLURL),
-				},
-			},
-		},
+// Transport lê e escreve mensagens JSON-RPC inteiras (um objeto ou um lote)
+// sobre um fluxo, cuidando do enquadramento. ReadMessage devolve io.EOF
+// quando o fluxo termina entre mensagens.
+type Transport interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(data []byte) error
+}
+
+// NewTransport cria o Transport do enquadramento informado; vazio usa o
+// padrão, uma mensagem por linha.
+func NewTransport(framing string, r io.Reader, w io.Writer) (Transport, error) {
+	switch strings.ToLower(strings.TrimSpace(framing)) {
+	case "", framingNewline:
+		return NewLineTransport(r, w), nil
+	case framingContentLength:
+		return NewContentLengthTransport(r, w), nil
+	default:
+		return nil, fmt.Errorf("enquadramento inválido: %q (use %s ou %s)", framing, framingNewline, framingContentLength)
 	}
 }
 
-func (s *MCPServer) handleGetRepos(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
-	username, _ := params.Arguments["username"].(string)
+// lineTransport troca uma mensagem JSON por linha.
+type lineTransport struct {
+	scanner *bufio.Scanner
+	w       io.Writer
+}
 
-	repos, err := s.github.GetRepos(ctx, username)
-	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
-				Data:    err.Error(),
-			},
+// NewLineTransport cria o Transport padrão, com uma mensagem JSON por linha.
+func NewLineTransport(r io.Reader, w io.Writer) Transport {
+	// O buffer padrão do Scanner para em linhas de 64 KiB, menores que um
+	// lote ou um corpo de issue grande.
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFramedMessageSize)
+	return &lineTransport{scanner: scanner, w: w}
+}
+
+func (t *lineTransport) ReadMessage() ([]byte, error) {
+	if !t.scanner.Scan() {
+		// Scan retorna false tanto no EOF quanto em erro de leitura; Err é
+		// nil no EOF.
+		if err := t.scanner.Err(); err != nil {
+			return nil, err
 		}
+		return nil, io.EOF
 	}
+	return []byte(t.scanner.Text()), nil
+}
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Repositórios (%d):\n\n", len(repos)))
-	for _, repo := range repos {
-		result.WriteString(fmt.Sprintf("- %s\n", repo.Name))
-		result.WriteString(fmt.Sprintf("  Descrição: %s\n", repo.Description))
-		result.WriteString(fmt.Sprintf("  Privado: %t\n", repo.Private))
-		result.WriteString(fmt.Sprintf("  URL: %s\n", repo.HTMLURL))
-		result.WriteString("\n")
-	}
+func (t *lineTransport) WriteMessage(data []byte) error {
+	_, err := fmt.Fprintln(t.w, string(data))
+	return err
+}
 
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: CallToolResult{
-			Content: []map[string]interface{}{
-				{
-					"type": "text",
-					"text": result.String(),
-				},
-			},
-		},
-	}
+// contentLengthTransport enquadra cada mensagem como no LSP: cabeçalhos
+// terminados por uma linha vazia, com Content-Length obrigatório, seguidos do
+// corpo com exatamente esse número de bytes.
+type contentLengthTransport struct {
+	r *bufio.Reader
+	w io.Writer
 }
 
-func (s *MCPServer) handleGetIssues(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
-	owner, _ := params.Arguments["owner"].(string)
-	repo, _ := params.Arguments["repo"].(string)
+// NewContentLengthTransport cria um Transport com enquadramento
+// "Content-Length: N\r\n\r\n<corpo>".
+func NewContentLengthTransport(r io.Reader, w io.Writer) Transport {
+	return &contentLengthTransport{r: bufio.NewReader(r), w: w}
+}
 
-	issues, err := s.github.GetIssues(ctx, owner, repo)
-	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
-				Data:    err.Error(),
-			},
+func (t *contentLengthTransport) ReadMessage() ([]byte, error) {
+	length := -1
+	headers := 0
+	for {
+		line, err := t.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && headers == 0 && line == "" {
+				return nil, io.EOF
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
 		}
-	}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if headers == 0 {
+				// Linhas vazias entre mensagens são toleradas.
+				continue
+			}
+			break
+		}
+		headers++
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Issues do %s/%s (%d):\n\n", owner, repo, len(issues)))
-	for _, issue := range issues {
-		result.WriteString(fmt.Sprintf("- #%d: %s\n", issue.Number, issue.Title))
-		result.WriteString(fmt.Sprintf("  Estado: %s\n", issue.State))
-		result.WriteString(fmt.Sprintf("  URL: %s\n", issue.HTMLURL))
-		result.WriteString("\n")
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("cabeçalho inválido: %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("Content-Length inválido: %q", value)
+			}
+			length = n
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("mensagem sem cabeçalho Content-Length")
+	}
+	if length > maxFramedMessageSize {
+		return nil, fmt.Errorf("Content-Length %d excede o limite de %d bytes", length, maxFramedMessageSize)
 	}
 
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: CallToolResult{
-			Content: []map[string]interface{}{
-				{
-					"type": "text",
-					"text": result.String(),
-				},
-			},
-		},
+	body := make([]byte, length)
+	if _, err := io.ReadFull(t.r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
 	}
+	return body, nil
 }
 
-func (s *MCPServer) handleGetPullRequests(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
-	owner, _ := params.Arguments["owner"].(string)
-	repo, _ := params.Arguments["repo"].(string)
+func (t *contentLengthTransport) WriteMessage(data []byte) error {
+	_, err := fmt.Fprintf(t.w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
 
-	prs, err := s.github.GetPullRequests(ctx, owner, repo)
-	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
-				Data:    err.Error(),
-			},
+// ErrIdleTimeout é devolvido por Serve quando nenhuma mensagem chega dentro
+// do idleTimeout do servidor.
+var ErrIdleTimeout = errors.New("nenhuma mensagem recebida dentro do IDLE_TIMEOUT")
+
+// Serve lê mensagens JSON-RPC de r, uma por linha, e escreve as respostas em
+// w até o EOF; é ServeTransport com o enquadramento padrão por linha.
+func Serve(ctx context.Context, r io.Reader, w io.Writer, server *MCPServer) error {
+	return ServeTransport(ctx, NewLineTransport(r, w), server)
+}
+
+// ServeTransport lê mensagens JSON-RPC de t e escreve as respostas nele até
+// o EOF. Retorna nil no EOF, ErrIdleTimeout se o servidor ficar ocioso além
+// de idleTimeout e o erro em falhas de leitura ou escrita.
+//
+// A leitura roda em uma goroutine própria para que o timeout ocioso e o
+// cancelamento de ctx não dependam de t retornar; se ServeTransport sair
+// antes do EOF, essa goroutine continua bloqueada em t até a próxima leitura.
+func ServeTransport(ctx context.Context, t Transport, server *MCPServer) error {
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			data, err := t.ReadMessage()
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- data:
+			case <-done:
+				return
+			}
 		}
+	}()
+
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if server.idleTimeout > 0 {
+		timer = time.NewTimer(server.idleTimeout)
+		defer timer.Stop()
+		idle = timer.C
 	}
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Pull Requests do %s/%s (%d):\n\n", owner, repo, len(prs)))
-	for _, pr := range prs {
-		result.WriteString(fmt.Sprintf("- #%d: %s\n", pr.Number, pr.Title))
-		result.WriteString(fmt.Sprintf("  Estado: %s\n", pr.State))
-		result.WriteString(fmt.Sprintf("  URL: %s\n", pr.HTMLURL))
-		result.WriteString("\n")
+	for {
+		var data []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle:
+			return ErrIdleTimeout
+		case err := <-readErr:
+			return err
+		case notification := <-server.notifications:
+			if err := server.writeResponse(t, notification); err != nil {
+				return err
+			}
+			continue
+		case data = <-messages:
+		}
+
+		if timer != nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(server.idleTimeout)
+		}
+
+		line := strings.TrimSpace(string(data))
+		if line == "" {
+			continue
+		}
+
+		// Um array JSON é um lote: as respostas saem juntas, em um único
+		// array, e dividem o mesmo limite de tamanho.
+		if strings.HasPrefix(line, "[") {
+			var batch []MCPMessage
+			if err := json.Unmarshal([]byte(line), &batch); err != nil {
+				log.Printf("Erro ao parsear JSON: %v", err)
+				continue
+			}
+			if len(batch) == 0 {
+				if err := server.writeResponse(t, errorResult(nil, -32600, "Invalid Request", "lote vazio")); err != nil {
+					return err
+				}
+				continue
+			}
+
+			responses := make([]MCPMessage, 0, len(batch))
+			for _, msg := range batch {
+				response := server.HandleMessage(ctx, msg)
+				if !isNotification(msg) {
+					responses = append(responses, response)
+				}
+			}
+			// Um lote só de notificações não tem resposta.
+			if len(responses) > 0 {
+				server.limitResponseSize(responses)
+				if err := server.writeResponse(t, responses); err != nil {
+					return err
+				}
+			}
+			if err := server.flushNotifications(t); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var msg MCPMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			log.Printf("Erro ao parsear JSON: %v", err)
+			continue
+		}
+
+		response := server.HandleMessage(ctx, msg)
+		if !isNotification(msg) {
+			responses := []MCPMessage{response}
+			server.limitResponseSize(responses)
+			if err := server.writeResponse(t, responses[0]); err != nil {
+				return err
+			}
+		}
+		if err := server.flushNotifications(t); err != nil {
+			return err
+		}
 	}
+}
 
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: CallToolResult{
-			Content: []map[string]interface{}{
-				{
-					"type": "text",
-					"text": result.String(),
-				},
-			},
-		},
+// flushNotifications escreve as notificações já enfileiradas, para que as
+// geradas ao tratar uma mensagem (como notifications/tools/list_changed)
+// saiam logo após a resposta dela.
+func (s *MCPServer) flushNotifications(t Transport) error {
+	for {
+		select {
+		case notification := <-s.notifications:
+			if err := s.writeResponse(t, notification); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
 	}
 }
 
-func (s *MCPServer) handleGetCommits(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
-	owner, _ := params.Arguments["owner"].(string)
-	repo, _ := params.Arguments["repo"].(string)
+// isNotification diz se msg é uma notificação JSON-RPC (sem id), que é
+// processada mas nunca recebe resposta.
+func isNotification(msg MCPMessage) bool {
+	return msg.ID == nil
+}
 
-	commits, err := s.github.GetCommits(ctx, owner, repo)
-	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
-				Data:    err.Error(),
-			},
+// resolveToken escolhe o token do GitHub, nesta ordem: a variável
+// GITHUB_TOKEN, o arquivo tokenFile (--token-file) e o oauth_token que o gh
+// CLI guardou em hosts.yml para o host de baseURL. Devolve também de onde o
+// token veio, para o log.
+func resolveToken(tokenFile, baseURL string) (string, string, error) {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token, "GITHUB_TOKEN", nil
+	}
+
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", "", fmt.Errorf("erro ao ler --token-file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", "", fmt.Errorf("--token-file %s está vazio", tokenFile)
 		}
+		return token, tokenFile, nil
 	}
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Commits do %s/%s (%d):\n\n", owner, repo, len(commits)))
-	for _, commit := range commits {
-		result.WriteString(fmt.Sprintf("- %s\n", commit.SHA[:7]))
-		result.WriteString(fmt.Sprintf("  Mensagem: %s\n", commit.Message))
-		result.WriteString(fmt.Sprintf("  Autor: %s (%s)\n", commit.Author.Name, commit.Author.Email))
-		result.WriteString(fmt.Sprintf("  Data: %s\n", commit.Author.Date))
-		result.WriteString("\n")
+	path := ghHostsPath()
+	if path != "" {
+		token, err := ghCLIToken(path, ghHost(baseURL))
+		if err != nil {
+			return "", "", err
+		}
+		if token != "" {
+			return token, path, nil
+		}
 	}
 
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: CallToolResult{
-			Content: []map[string]interface{}{
-				{
-					"type": "text",
-					"text": result.String(),
-				},
-			},
-		},
+	return "", "", errNoToken
+}
+
+// errNoToken é devolvido por resolveToken quando nenhuma fonte traz um token;
+// o servidor inicia mesmo assim, sem as ferramentas de escrita.
+var errNoToken = errors.New("GITHUB_TOKEN não definido (nem --token-file nem token do gh CLI encontrados)")
+
+// ghHostsPath devolve o hosts.yml do gh CLI, respeitando GH_CONFIG_DIR e
+// XDG_CONFIG_HOME como o próprio gh; "" se não houver diretório home.
+func ghHostsPath() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
 	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml")
 }
 
-func (s *MCPServer) handleGetContent(ctx context.Context, msg MCPMessage, params CallToolParams) MCPMessage {
-	owner, _ := params.Arguments["owner"].(string)
-	repo, _ := params.Arguments["repo"].(string)
-	path, _ := params.Arguments["path"].(string)
+// ghHost é a chave do hosts.yml para a API em baseURL: github.com para
+// api.github.com e o próprio host no GitHub Enterprise Server.
+func ghHost(baseURL string) string {
+	u, err := url.Parse(normalizeBaseURL(baseURL))
+	if err != nil || u.Hostname() == defaultAPIHost {
+		return "github.com"
+	}
+	return u.Hostname()
+}
 
-	content, err := s.github.GetContent(ctx, owner, repo, path)
+// ghCLIToken lê o oauth_token de host no hosts.yml do gh CLI; "" sem erro se
+// o arquivo não existe ou não traz o token (o gh pode guardá-lo no chaveiro
+// do sistema). O arquivo é lido linha a linha, sem um parser YAML: vale o
+// oauth_token direto do host, que é o do usuário ativo, e na falta dele o
+// primeiro que aparecer dentro do host (formato users: do gh mais novo).
+func ghCLIToken(path, host string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
 	if err != nil {
-		return MCPMessage{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
-				Data:    err.Error(),
-			},
-		}
+		return "", fmt.Errorf("erro ao ler %s: %w", path, err)
 	}
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Conteúdo de %s/%s/%s:\n\n", owner, repo, path))
-	result.WriteString(fmt.Sprintf("Tipo: %s\n", content.Type))
-	result.WriteString(fmt.Sprintf("Tamanho: %d bytes\n", content.Size))
-	result.WriteString(fmt.Sprintf("URL: %s\n", content.HTMLURL))
+	inHost := false
+	childIndent := -1
+	var direct, nested string
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			key := strings.Trim(strings.TrimSuffix(trimmed, ":"), `"'`)
+			inHost = key == host
+			childIndent = -1
+			continue
+		}
+		if !inHost {
+			continue
+		}
+		if childIndent < 0 {
+			childIndent = indent
+		}
 
-	if content.Content != "" {
-		result.WriteString("\nConteúdo:\n")
-		result.WriteString(content.Content)
+		value, ok := strings.CutPrefix(trimmed, "oauth_token:")
+		if !ok {
+			continue
+		}
+		token := strings.Trim(strings.TrimSpace(value), `"'`)
+		if indent == childIndent && direct == "" {
+			direct = token
+		} else if nested == "" {
+			nested = token
+		}
 	}
 
-	return MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: CallToolResult{
-			Content: []map[string]interface{}{
-				{
-					"type": "text",
-					"text": result.String(),
-				},
-			},
-		},
+	if direct != "" {
+		return direct, nil
 	}
+	return nested, nil
 }
 
 func main() {
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("GITHUB_TOKEN não definido")
+	webhookAddr := flag.String("webhook-addr", "", "endereço (ex.: :8080) para receber webhooks do GitHub; exige WEBHOOK_SECRET")
+	tokenFile := flag.String("token-file", "", "arquivo com o token do GitHub, usado se GITHUB_TOKEN não estiver definido")
+	framing := flag.String("framing", os.Getenv("MCP_FRAMING"), "enquadramento das mensagens em stdin/stdout: newline (padrão) ou content-length")
+	flag.Parse()
+
+	baseURL := os.Getenv("GITHUB_API_URL")
+	token, source, err := resolveToken(*tokenFile, baseURL)
+	switch {
+	case errors.Is(err, errNoToken):
+		log.Printf("%v; iniciando sem token: só dados públicos e sem as ferramentas de escrita, até um initialize trazer o token da sessão", err)
+	case err != nil:
+		log.Fatal(err)
+	case source != "GITHUB_TOKEN":
+		log.Printf("Usando o token do GitHub de %s", source)
 	}
 
-	server := NewMCPServer(token)
-	ctx := context.Background()
+	server := NewMCPServer(token, baseURL)
+	if err := configureFromEnv(server); err != nil {
+		log.Fatal(err)
+	}
 
-	log.Println("Servidor MCP GitHub iniciado")
-	log.Println("Aguardando mensagens via stdin...")
+	transport, err := NewTransport(*framing, os.Stdin, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
+	if *webhookAddr != "" {
+		secret := os.Getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			log.Fatal("--webhook-addr exige WEBHOOK_SECRET para validar as entregas")
 		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			log.Printf("Erro ao parsear JSON: %v", err)
-			continue
+		webhookServer := &http.Server{
+			Addr:              *webhookAddr,
+			Handler:           server.WebhookHandler(secret),
+			ReadHeaderTimeout: webhookReadHeaderTimeout,
+			ReadTimeout:       webhookReadTimeout,
+			WriteTimeout:      webhookWriteTimeout,
 		}
-
-		response := server.HandleMessage(ctx, msg)
-		responseJSON, _ := json.Marshal(response)
-		fmt.Println(string(responseJSON))
+		go func() {
+			log.Printf("Recebendo webhooks do GitHub em %s", *webhookAddr)
+			if err := webhookServer.ListenAndServe(); err != nil {
+				log.Printf("Servidor de webhooks encerrado: %v", err)
+			}
+		}()
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Printf("Erro ao ler stdin: %v", err)
+	log.Println("Servidor MCP GitHub iniciado")
+	log.Println("Aguardando mensagens via stdin...")
+
+	err = ServeTransport(context.Background(), transport, server)
+	if errors.Is(err, ErrIdleTimeout) {
+		log.Printf("Nenhuma mensagem em %s (IDLE_TIMEOUT), finalizando servidor", server.idleTimeout)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro no loop de mensagens: %v", err)
+		os.Exit(1)
 	}
-}
\ No newline at end of file
+	log.Println("stdin encerrado (EOF), finalizando servidor")
+}
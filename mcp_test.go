@@ -0,0 +1,1390 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer sobe um httptest.Server com handler e devolve um MCPServer
+// já inicializado apontando para ele.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *MCPServer {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	server := NewMCPServer("test-token", ts.URL)
+	server.defaultClient.allowInsecure = true
+	server.defaultClient.allowedHosts = append(server.defaultClient.allowedHosts, "127.0.0.1")
+	server.initializeReceived = true
+	return server
+}
+
+// callTool chama a ferramenta name por tools/call.
+func callTool(t *testing.T, server *MCPServer, name string, args map[string]interface{}) MCPMessage {
+	t.Helper()
+	return server.HandleMessage(context.Background(), MCPMessage{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": name, "arguments": args},
+	})
+}
+
+// resultText devolve o texto do primeiro bloco de uma resposta de sucesso.
+func resultText(t *testing.T, msg MCPMessage) string {
+	t.Helper()
+	if msg.Error != nil {
+		t.Fatalf("erro inesperado: %d %s: %s", msg.Error.Code, msg.Error.Message, msg.Error.Data)
+	}
+	result, ok := msg.Result.(CallToolResult)
+	if !ok || len(result.Content) == 0 {
+		t.Fatalf("resultado sem conteúdo: %#v", msg.Result)
+	}
+	text, _ := result.Content[0]["text"].(string)
+	return text
+}
+
+func TestCallToolResultEmptyContent(t *testing.T) {
+	data, err := json.Marshal(CallToolResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"content":[]`) {
+		t.Errorf("content deveria ser [], veio %s", data)
+	}
+	if strings.Contains(string(data), "null") {
+		t.Errorf("resultado vazio não deveria ter null: %s", data)
+	}
+}
+
+func TestListRunArtifactsDownloadConfinedToArtifactDir(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/zip") {
+			w.Write([]byte("PK-zip"))
+			return
+		}
+		w.Write([]byte(`{"artifacts":[{"id":7,"name":"build","size_in_bytes":6}]}`))
+	})
+	args := func(path string) map[string]interface{} {
+		return map[string]interface{}{"repo": "o/r", "run_id": 1, "download": true, "name": "build", "path": path}
+	}
+
+	if msg := callTool(t, server, "list_run_artifacts", args("")); msg.Error == nil {
+		t.Fatal("download sem ARTIFACT_DIR deveria ser recusado")
+	}
+
+	server.artifactDir = t.TempDir()
+	for _, path := range []string{"/tmp/x.zip", "../x.zip", "a/../../x.zip"} {
+		msg := callTool(t, server, "list_run_artifacts", args(path))
+		if msg.Error == nil || msg.Error.Code != -32602 {
+			t.Errorf("path %q deveria ser recusado, veio %+v", path, msg.Error)
+		}
+	}
+
+	text := resultText(t, callTool(t, server, "list_run_artifacts", args("")))
+	target := filepath.Join(server.artifactDir, "build.zip")
+	if data, err := os.ReadFile(target); err != nil || string(data) != "PK-zip" {
+		t.Fatalf("arquivo baixado: %q, %v", data, err)
+	}
+	if !strings.Contains(text, target) {
+		t.Errorf("resposta deveria citar %s:\n%s", target, text)
+	}
+
+	// O_EXCL: um arquivo existente nunca é sobrescrito.
+	if msg := callTool(t, server, "list_run_artifacts", args("")); msg.Error == nil {
+		t.Error("download sobre arquivo existente deveria falhar")
+	}
+	// Em READ_ONLY só o download é recusado; a listagem continua.
+	server.readOnly = true
+	listing := args("")
+	delete(listing, "download")
+	if text := resultText(t, callTool(t, server, "list_run_artifacts", listing)); !strings.Contains(text, "build") {
+		t.Errorf("listagem em READ_ONLY deveria funcionar:\n%s", text)
+	}
+	if msg := callTool(t, server, "list_run_artifacts", args("outro.zip")); msg.Error == nil || !strings.Contains(msg.Error.Data, "somente leitura") {
+		t.Errorf("download em READ_ONLY deveria ser recusado, veio %+v", msg.Error)
+	}
+}
+
+func TestDefaultBranchCacheIgnoresCase(t *testing.T) {
+	repoRequests := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.URL.Path, "/repos/octo/hello") {
+			repoRequests++
+			w.Write([]byte(`{"name":"hello","default_branch":"trunk"}`))
+			return
+		}
+		w.Write([]byte(`{"tree":[]}`))
+	})
+
+	for _, repo := range []string{"Octo/Hello", "octo/hello"} {
+		text := resultText(t, callTool(t, server, "largest_files", map[string]interface{}{"repo": repo}))
+		if !strings.Contains(text, repo) {
+			t.Errorf("a resposta deveria manter a grafia %s:\n%s", repo, text)
+		}
+	}
+	if repoRequests != 1 {
+		t.Errorf("Octo/Hello e octo/hello deveriam dividir o cache; %d buscas do repositório", repoRequests)
+	}
+}
+
+func TestVerificationStatus(t *testing.T) {
+	cases := []struct {
+		v    *GitHubVerification
+		want string
+	}{
+		{nil, "✘ não verificada (sem dados de verificação)"},
+		{&GitHubVerification{Verified: true, Reason: "valid"}, "✔ verificada"},
+		{&GitHubVerification{Reason: "unsigned"}, "✘ não verificada (unsigned)"},
+	}
+	for _, c := range cases {
+		if got := verificationStatus(c.v); got != c.want {
+			t.Errorf("verificationStatus(%+v) = %q, want %q", c.v, got, c.want)
+		}
+	}
+}
+
+func TestBatchResponseSizeBudget(t *testing.T) {
+	content := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("linha de código\n", 400)))
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GitHubContent{Name: "big.txt", Type: "file", Size: 6400, Content: content, Encoding: "base64"})
+	})
+	server.maxResponseSize = 3000
+
+	var batch []string
+	for id := 1; id <= 3; id++ {
+		batch = append(batch, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"get_content","arguments":{"repo":"o/r","path":"big.txt"}}}`, id))
+	}
+	var out bytes.Buffer
+	if err := Serve(context.Background(), strings.NewReader("["+strings.Join(batch, ",")+"]\n"), &out, server); err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []struct {
+		Result CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &responses); err != nil {
+		t.Fatalf("saída não é um array JSON: %v\n%s", err, out.String())
+	}
+	if len(responses) != 3 {
+		t.Fatalf("esperava 3 respostas, vieram %d", len(responses))
+	}
+	total := 0
+	for i, response := range responses {
+		text, _ := response.Result.Content[0]["text"].(string)
+		total += len(text)
+		if !strings.Contains(text, "[conteúdo truncado em") {
+			t.Errorf("resposta %d sem aviso de truncamento", i)
+		}
+	}
+	if total > server.maxResponseSize {
+		t.Errorf("texto total %d excede o limite de %d bytes", total, server.maxResponseSize)
+	}
+}
+
+func TestJSONOutputTruncatedWholeItems(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/users/") && !strings.HasSuffix(r.URL.Path, "/repos") {
+			json.NewEncoder(w).Encode(GitHubUser{Login: "octocat", Bio: strings.Repeat("bio ", 500)})
+			return
+		}
+		var repos []GitHubRepo
+		for i := 0; i < 30; i++ {
+			repos = append(repos, GitHubRepo{Name: fmt.Sprintf("repo-%d", i), Description: strings.Repeat("descrição ", 10)})
+		}
+		json.NewEncoder(w).Encode(repos)
+	})
+	server.maxResponseSize = 2000
+
+	out := serveLines(t, server, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_repos","arguments":{"username":"octocat","output":"json"}}}`+"\n"+
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_user","arguments":{"username":"octocat","output":"json"}}}`+"\n")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("esperava 2 respostas, vieram %d:\n%s", len(lines), out)
+	}
+
+	var list struct {
+		Result CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &list); err != nil {
+		t.Fatal(err)
+	}
+	text, _ := list.Result.Content[0]["text"].(string)
+	var repos []GitHubRepo
+	if err := json.Unmarshal([]byte(text), &repos); err != nil {
+		t.Fatalf("JSON truncado não é válido: %v\n%s", err, text)
+	}
+	if len(repos) == 0 || len(repos) >= 30 {
+		t.Errorf("esperava parte dos 30 repositórios, vieram %d", len(repos))
+	}
+	if len(list.Result.Content) != 2 {
+		t.Fatalf("esperava o aviso em um bloco à parte, content = %v", list.Result.Content)
+	}
+	notice, _ := list.Result.Content[1]["text"].(string)
+	if want := fmt.Sprintf("reduzida a %d de 30 itens", len(repos)); !strings.Contains(notice, want) {
+		t.Errorf("aviso = %q, esperava %q", notice, want)
+	}
+	if len(text)+len(notice) > server.maxResponseSize {
+		t.Errorf("texto total %d excede o limite de %d bytes", len(text)+len(notice), server.maxResponseSize)
+	}
+
+	var object MCPMessage
+	if err := json.Unmarshal([]byte(lines[1]), &object); err != nil {
+		t.Fatal(err)
+	}
+	if object.Error == nil || object.Error.Code != -32603 || !strings.Contains(object.Error.Data, "excede o limite") {
+		t.Errorf("objeto JSON grande demais deveria virar erro -32603, veio %s", lines[1])
+	}
+}
+
+func TestRetriesDroppedConnection(t *testing.T) {
+	requests := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	})
+	server.defaultClient.networkRetryDelay = time.Millisecond
+
+	text := resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}))
+	if !strings.Contains(text, "octocat") {
+		t.Errorf("resposta inesperada:\n%s", text)
+	}
+	if requests != 2 {
+		t.Errorf("esperava 2 requisições (1 derrubada + 1 nova tentativa), vieram %d", requests)
+	}
+}
+
+func TestWebhookOmitsOversizedPayload(t *testing.T) {
+	server := NewMCPServer("test-token", "")
+	server.maxResponseSize = 200
+	handler := server.WebhookHandler("segredo")
+
+	deliver := func(body string) map[string]interface{} {
+		t.Helper()
+		mac := hmac.New(sha256.New, []byte("segredo"))
+		mac.Write([]byte(body))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "issues")
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		notification := <-server.notifications
+		return notification.Params.(map[string]interface{})
+	}
+
+	small := deliver(`{"action":"opened","repository":{"full_name":"o/r"},"sender":{"login":"ana"}}`)
+	if _, ok := small["payload"]; !ok {
+		t.Error("payload pequeno deveria ser repassado")
+	}
+
+	big := `{"action":"opened","repository":{"full_name":"o/r"},"sender":{"login":"ana"},"issue":{"body":"` + strings.Repeat("x", 500) + `"}}`
+	params := deliver(big)
+	if _, ok := params["payload"]; ok {
+		t.Error("payload acima de maxResponseSize não deveria ser repassado")
+	}
+	if params["payload_omitted_bytes"] != len(big) || params["action"] != "opened" || params["repository"] != "o/r" || params["sender"] != "ana" {
+		t.Errorf("resumo incompleto: %v", params)
+	}
+}
+
+func TestDecodeContentAndBinaryFiles(t *testing.T) {
+	// A API devolve o base64 quebrado em linhas de 60 caracteres.
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("olá, mundo\n", 20)))
+	var wrapped strings.Builder
+	for len(encoded) > 60 {
+		wrapped.WriteString(encoded[:60] + "\n")
+		encoded = encoded[60:]
+	}
+	wrapped.WriteString(encoded + "\n")
+
+	text, err := decodeContent(&GitHubContent{Content: wrapped.String(), Encoding: "base64"})
+	if err != nil || text != strings.Repeat("olá, mundo\n", 20) {
+		t.Fatalf("decodeContent = %q, %v", text, err)
+	}
+
+	binary := base64.StdEncoding.EncodeToString([]byte("PNG\x00\xff\xfe"))
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GitHubContent{Name: "logo.png", Path: "logo.png", Type: "file", Size: 6, Content: binary, Encoding: "base64"})
+	})
+	calls := map[string]map[string]interface{}{
+		"get_content":        {"repo": "o/r", "path": "logo.png"},
+		"get_files":          {"repo": "o/r", "paths": []interface{}{"logo.png"}},
+		"get_file_at_commit": {"repo": "o/r", "path": "logo.png", "sha": "abc1234"},
+	}
+	for name, args := range calls {
+		text := resultText(t, callTool(t, server, name, args))
+		if !strings.Contains(text, "[arquivo binário: conteúdo omitido]") {
+			t.Errorf("%s deveria omitir o conteúdo binário:\n%s", name, text)
+		}
+		if strings.ContainsRune(text, '�') {
+			t.Errorf("%s emitiu bytes inválidos:\n%s", name, text)
+		}
+	}
+}
+
+func TestPaginationFollowsLinkHeader(t *testing.T) {
+	requests := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"name":"gama","starred_at":"2024-02-01T00:00:00Z"}]`))
+			return
+		}
+		next := "http://" + r.Host + r.URL.Path + "?page=2"
+		w.Header().Set("Link", `<`+next+`>; rel="next", <`+next+`>; rel="last"`)
+		w.Write([]byte(`[{"name":"alfa","starred_at":"2024-01-01T00:00:00Z"},{"name":"beta","starred_at":"2024-01-02T00:00:00Z"}]`))
+	})
+
+	text := resultText(t, callTool(t, server, "get_repos", map[string]interface{}{"username": "ana", "all": true}))
+	if requests != 2 || !strings.Contains(text, "Repositórios (3)") {
+		t.Errorf("all deveria concatenar as duas páginas (%d requisições):\n%s", requests, text)
+	}
+	for _, name := range []string{"alfa", "beta", "gama"} {
+		if !strings.Contains(text, name) {
+			t.Errorf("falta %s:\n%s", name, text)
+		}
+	}
+
+	requests = 0
+	text = resultText(t, callTool(t, server, "get_repos", map[string]interface{}{"username": "ana"}))
+	if requests != 1 || !strings.Contains(text, "Repositórios (2)") {
+		t.Errorf("sem all deveria buscar só a primeira página (%d requisições):\n%s", requests, text)
+	}
+
+	requests = 0
+	text = resultText(t, callTool(t, server, "star_history", map[string]interface{}{"repo": "o/r", "max_pages": 1}))
+	if requests != 1 || !strings.Contains(text, "limite de 1 páginas atingido") {
+		t.Errorf("max_pages 1 deveria parar na primeira página (%d requisições):\n%s", requests, text)
+	}
+
+	text = resultText(t, callTool(t, server, "get_repos", map[string]interface{}{"username": "ana", "all": true, "limit": 1}))
+	if !strings.Contains(text, "Repositórios (3)") || !strings.Contains(text, "alfa") || strings.Contains(text, "beta") {
+		t.Errorf("limit 1 deveria manter só o primeiro item:\n%s", text)
+	}
+}
+
+func TestRateLimitErrorCarriesResetTime(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	requests := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	})
+
+	_, err := server.github.GetUser(context.Background(), "octocat")
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("esperava *RateLimitError, veio %T: %v", err, err)
+	}
+	if !rateErr.Reset.Equal(reset) || rateErr.RequestID != "ABCD:1234" {
+		t.Errorf("RateLimitError = %+v", rateErr)
+	}
+
+	requests = 0
+	msg := callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"})
+	if msg.Error == nil {
+		t.Fatal("esperava erro de limite de requisições")
+	}
+	if !strings.Contains(msg.Error.Data, "renova às "+reset.Format("15:04:05")) {
+		t.Errorf("o erro MCP deveria trazer a hora de renovação: %q", msg.Error.Data)
+	}
+	if requests != 1 {
+		t.Errorf("limite primário não deveria ter novas tentativas; %d requisições", requests)
+	}
+}
+
+func TestRetriesTransientServerErrors(t *testing.T) {
+	attempts := 0
+	failures := 2
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= failures {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	})
+	server.defaultClient.networkRetryDelay = time.Millisecond
+
+	text := resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}))
+	if !strings.Contains(text, "octocat") || attempts != 3 {
+		t.Errorf("esperava duas falhas e sucesso na 3ª tentativa, vieram %d tentativas:\n%s", attempts, text)
+	}
+
+	// Com saldo para só 2 novas tentativas, a primeira chamada esgota o
+	// orçamento e a seguinte falha sem repetir.
+	server.defaultClient.retries = newRetryBudget(2)
+	attempts, failures = 0, 100
+	if msg := callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}); msg.Error == nil {
+		t.Fatal("esperava erro com o 502 persistente")
+	}
+	if attempts != 3 {
+		t.Errorf("esperava 1 tentativa + 2 repetições, vieram %d", attempts)
+	}
+	attempts = 0
+	if msg := callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}); msg.Error == nil {
+		t.Fatal("esperava erro com o orçamento esgotado")
+	}
+	if attempts != 1 {
+		t.Errorf("sem saldo no orçamento não deveria repetir; %d tentativas", attempts)
+	}
+}
+
+func TestCustomBaseURL(t *testing.T) {
+	var gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"login":"x"}`))
+	}))
+	defer ts.Close()
+
+	client := NewGitHubClient("test-token", ts.URL+"/api/v3//")
+	client.allowInsecure = true
+	client.allowedHosts = append(client.allowedHosts, "127.0.0.1")
+	if _, err := client.GetUser(context.Background(), "x"); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/api/v3/users/x" {
+		t.Errorf("caminho = %q, want /api/v3/users/x", gotPath)
+	}
+
+	if got := NewGitHubClient("test-token", "").baseURL; got != defaultBaseURL {
+		t.Errorf("baseURL vazia = %q, want %q", got, defaultBaseURL)
+	}
+
+	gotPath = ""
+	blocked := NewGitHubClient("test-token", ts.URL)
+	blocked.allowInsecure = true
+	_, err := blocked.GetUser(context.Background(), "x")
+	if err == nil || !strings.Contains(err.Error(), "não está na lista de hosts permitidos") {
+		t.Errorf("host fora da lista deveria ser rejeitado, veio %v", err)
+	}
+	if gotPath != "" {
+		t.Errorf("nenhuma requisição deveria sair para um host rejeitado; veio %q", gotPath)
+	}
+}
+
+func TestOutputTextAndJSON(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/repos/") {
+			json.NewEncoder(w).Encode(GitHubContent{Name: "a.txt", Path: "a.txt", Type: "file", Size: 3, Content: base64.StdEncoding.EncodeToString([]byte("oi\n")), Encoding: "base64"})
+			return
+		}
+		w.Write([]byte(`{"login":"octocat","name":"The Octocat","location":"SF"}`))
+	})
+
+	text := resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}))
+	if !strings.Contains(text, "The Octocat") || json.Valid([]byte(text)) {
+		t.Errorf("o padrão deveria ser texto formatado:\n%s", text)
+	}
+
+	text = resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat", "output": "json"}))
+	var user GitHubUser
+	if err := json.Unmarshal([]byte(text), &user); err != nil || user.Login != "octocat" || user.Location != "SF" {
+		t.Errorf("output json de get_user = %s (%v)", text, err)
+	}
+
+	text = resultText(t, callTool(t, server, "get_content", map[string]interface{}{"repo": "o/r", "path": "a.txt", "output": "json"}))
+	var content GitHubContent
+	if err := json.Unmarshal([]byte(text), &content); err != nil || content.Content != "oi\n" || content.Encoding != "" {
+		t.Errorf("output json de get_content deveria trazer o texto decodificado: %s (%v)", text, err)
+	}
+
+	if msg := callTool(t, server, "get_files", map[string]interface{}{"repo": "o/r", "paths": []interface{}{"a.txt"}, "output": "json"}); msg.Error == nil || msg.Error.Code != -32602 {
+		t.Errorf("ferramenta sem output deveria recusar json, veio %+v", msg.Error)
+	}
+}
+
+func TestStructuredContentMarshal(t *testing.T) {
+	msg := structuredResult(1, "texto", structuredData([]GitHubRepo{{Name: "alfa"}}))
+	data, err := json.Marshal(msg.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Content           []map[string]interface{} `json:"content"`
+		StructuredContent struct {
+			Items []GitHubRepo `json:"items"`
+		} `json:"structuredContent"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Content) != 1 || decoded.Content[0]["text"] != "texto" {
+		t.Errorf("content = %v", decoded.Content)
+	}
+	if len(decoded.StructuredContent.Items) != 1 || decoded.StructuredContent.Items[0].Name != "alfa" {
+		t.Errorf("structuredContent = %s", data)
+	}
+
+	data, err = json.Marshal(textResult(1, "texto").Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "structuredContent") {
+		t.Errorf("structuredContent nulo deveria ser omitido: %s", data)
+	}
+}
+
+func TestStructuredContentMatchesOutputSchema(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/octocat/repos":
+			json.NewEncoder(w).Encode([]GitHubRepo{{Name: "alfa", Parent: &GitHubRepo{Name: "origem"}}})
+		case "/repos/o/r/issues":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(GitHubIssue{Number: 7, Title: "Bug"})
+		case "/repos/o/r/pages":
+			http.NotFound(w, r)
+		default:
+			t.Errorf("requisição inesperada: %s", r.URL.Path)
+		}
+	})
+
+	calls := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"get_repos", map[string]interface{}{"username": "octocat"}},
+		{"create_issue", map[string]interface{}{"repo": "o/r", "title": "Bug"}},
+		{"get_pages", map[string]interface{}{"repo": "o/r"}},
+	}
+	for _, call := range calls {
+		msg := callTool(t, server, call.tool, call.args)
+		resultText(t, msg)
+		result := msg.Result.(CallToolResult)
+		if result.StructuredContent == nil {
+			t.Errorf("%s: sem structuredContent", call.tool)
+			continue
+		}
+		tool, _ := server.findTool(call.tool)
+		if tool.OutputSchema == nil {
+			t.Errorf("%s devolve structuredContent sem outputSchema", call.tool)
+			continue
+		}
+		data, _ := json.Marshal(result.StructuredContent)
+		var value interface{}
+		json.Unmarshal(data, &value)
+		if err := conformsTo(tool.OutputSchema, value); err != nil {
+			t.Errorf("%s: structuredContent fora do outputSchema: %v\n%s", call.tool, err, data)
+		}
+	}
+
+	// Toda ferramenta com output json devolve structuredContent, e portanto
+	// precisa do outputSchema.
+	for _, tool := range server.tools {
+		properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+		if _, ok := properties["output"]; ok && tool.OutputSchema == nil {
+			t.Errorf("%s aceita output json mas não declara outputSchema", tool.Name)
+		}
+	}
+}
+
+// conformsTo checa os tipos e os campos declarados de value contra schema,
+// o subconjunto de JSON Schema que outputSchemaOf gera.
+func conformsTo(schema map[string]interface{}, value interface{}) error {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	}
+	kind := "null"
+	switch value.(type) {
+	case map[string]interface{}:
+		kind = "object"
+	case []interface{}:
+		kind = "array"
+	case string:
+		kind = "string"
+	case bool:
+		kind = "boolean"
+	case float64:
+		kind = "number"
+	}
+	if len(types) > 0 && !containsString(types, kind) && !(kind == "number" && containsString(types, "integer")) {
+		return fmt.Errorf("%v não é do tipo %v", value, types)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if properties == nil {
+			return nil
+		}
+		for name, field := range v {
+			prop, ok := properties[name].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("campo %q não declarado", name)
+			}
+			if err := conformsTo(prop, field); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for _, item := range v {
+			if err := conformsTo(items, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestStructuredContentCountsTowardsResponseSize(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GitHubUser{Login: "octocat", Bio: strings.Repeat("bio ", 100)})
+	})
+	text := resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}))
+	// Cabe o texto, mas não o texto somado ao structuredContent.
+	server.maxResponseSize = len(text) + 100
+
+	out := serveLines(t, server, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_user","arguments":{"username":"octocat"}}}`+"\n")
+	var response struct {
+		Result CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(out), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Result.StructuredContent != nil {
+		t.Errorf("structuredContent deveria sair da resposta acima do limite: %s", out)
+	}
+	if got, _ := response.Result.Content[0]["text"].(string); got != text {
+		t.Errorf("texto não deveria ser truncado quando cabe sozinho:\n%s", got)
+	}
+}
+
+func TestToolTimeoutSeconds(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	for _, timeout := range []interface{}{0, -1, 301, "abc"} {
+		msg := callTool(t, server, "get_user", map[string]interface{}{"username": "octocat", "timeout_seconds": timeout})
+		if msg.Error == nil || msg.Error.Code != -32602 {
+			t.Errorf("timeout_seconds %v deveria ser recusado, veio %+v", timeout, msg.Error)
+		}
+	}
+
+	start := time.Now()
+	msg := callTool(t, server, "get_user", map[string]interface{}{"username": "octocat", "timeout_seconds": 1})
+	if msg.Error == nil || !strings.Contains(msg.Error.Data, context.DeadlineExceeded.Error()) {
+		t.Errorf("esperava deadline exceeded, veio %+v", msg.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("a chamada deveria parar perto de 1s, levou %s", elapsed)
+	}
+}
+
+// serveLines passa input por Serve e devolve tudo o que foi escrito.
+func serveLines(t *testing.T, server *MCPServer, input string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Serve(context.Background(), strings.NewReader(input), &out, server); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestBatchRequests(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	out := serveLines(t, server, `[{"jsonrpc":"2.0","id":"a","method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"tools/list"}]`+"\n")
+	var responses []MCPMessage
+	if err := json.Unmarshal([]byte(out), &responses); err != nil {
+		t.Fatalf("saída não é um array JSON: %v\n%s", err, out)
+	}
+	if strings.Count(out, "\n") != 1 {
+		t.Errorf("o lote deveria sair em uma única linha:\n%s", out)
+	}
+	if len(responses) != 2 || responses[0].ID != "a" || responses[1].ID != float64(2) {
+		t.Fatalf("respostas com ids errados: %s", out)
+	}
+	for _, response := range responses {
+		if response.Error != nil {
+			t.Errorf("erro inesperado na resposta %v: %+v", response.ID, response.Error)
+		}
+	}
+
+	if out := serveLines(t, server, `[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","method":"ping"}]`+"\n"); out != "" {
+		t.Errorf("lote só de notificações não deveria ter resposta:\n%s", out)
+	}
+}
+
+func TestNotificationsHaveNoResponse(t *testing.T) {
+	server := newTestServer(t, nil)
+	input := `{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"ping"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"tools/list"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"metodo/inexistente"}` + "\n"
+	if out := serveLines(t, server, input); out != "" {
+		t.Errorf("mensagens sem id não deveriam gerar saída, veio %d bytes:\n%s", len(out), out)
+	}
+}
+
+func TestInitializeHandshake(t *testing.T) {
+	server := NewMCPServer("test-token", "")
+	call := func(id int, method string) MCPMessage {
+		return server.HandleMessage(context.Background(), MCPMessage{
+			JSONRPC: "2.0",
+			ID:      id,
+			Method:  method,
+			Params:  map[string]interface{}{"name": "whoami", "arguments": map[string]interface{}{}},
+		})
+	}
+
+	for _, method := range []string{"tools/list", "tools/call"} {
+		if msg := call(1, method); msg.Error == nil || msg.Error.Code != -32002 {
+			t.Errorf("%s antes do initialize deveria dar -32002, veio %+v", method, msg.Error)
+		}
+	}
+
+	if msg := call(2, "initialize"); msg.Error != nil {
+		t.Fatalf("initialize falhou: %+v", msg.Error)
+	}
+	server.HandleMessage(context.Background(), MCPMessage{JSONRPC: "2.0", Method: "notifications/initialized"})
+	msg := call(3, "tools/list")
+	if msg.Error != nil {
+		t.Fatalf("tools/list depois do initialize falhou: %+v", msg.Error)
+	}
+	if result, ok := msg.Result.(map[string]interface{}); !ok || len(result["tools"].([]Tool)) == 0 {
+		t.Errorf("tools/list sem ferramentas: %#v", msg.Result)
+	}
+}
+
+func TestToolsListChangedAfterSessionToken(t *testing.T) {
+	// Como o main faz quando nenhuma fonte traz token.
+	server := NewMCPServer("", "")
+
+	listed := func(line string) map[string]bool {
+		var msg struct {
+			Result struct {
+				Tools []Tool `json:"tools"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("tools/list inválido: %v\n%s", err, line)
+		}
+		names := make(map[string]bool)
+		for _, tool := range msg.Result.Tools {
+			names[tool.Name] = true
+		}
+		return names
+	}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}` + "\n" +
+		`{"jsonrpc":"2.0","id":3,"method":"initialize","params":{"capabilities":{"experimental":{"githubToken":"sessao"}}}}` + "\n" +
+		`{"jsonrpc":"2.0","id":4,"method":"tools/list"}` + "\n"
+	lines := strings.Split(strings.TrimSpace(serveLines(t, server, input)), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("esperava 4 respostas e 1 notificação, vieram %d linhas:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if strings.Contains(lines[0], "list_changed") {
+		t.Error("initialize sem token não muda as ferramentas e não deveria notificar")
+	}
+	if before := listed(lines[1]); before["create_issue"] || !before["get_user"] {
+		t.Errorf("sem token, create_issue não deveria ser anunciada: %v", before)
+	}
+	if lines[3] != `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}` {
+		t.Errorf("esperava a notificação logo após o initialize com token, veio %s", lines[3])
+	}
+	if after := listed(lines[4]); !after["create_issue"] {
+		t.Errorf("com o token da sessão, create_issue deveria ser anunciada: %v", after)
+	}
+}
+
+func TestAnonymousRequestsWithoutToken(t *testing.T) {
+	var authorization []string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"login":"octocat"}`))
+	})
+	server.defaultClient.token = ""
+	server.github = server.defaultClient
+
+	resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}))
+	server.HandleMessage(context.Background(), MCPMessage{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: map[string]interface{}{
+		"capabilities": map[string]interface{}{"experimental": map[string]interface{}{"githubToken": "sessao"}},
+	}})
+	resultText(t, callTool(t, server, "get_user", map[string]interface{}{"username": "octocat"}))
+
+	if len(authorization) != 2 || authorization[0] != "" || authorization[1] != "token sessao" {
+		t.Errorf("Authorization = %q, esperava vazio sem token e o token da sessão depois", authorization)
+	}
+}
+
+func TestTransportFraming(t *testing.T) {
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	var out bytes.Buffer
+	framed := fmt.Sprintf("Content-Length: %d\r\nContent-Type: application/json\r\n\r\n%s\r\n", len(ping), ping)
+	if err := ServeTransport(context.Background(), NewContentLengthTransport(strings.NewReader(framed), &out), newTestServer(t, nil)); err != nil {
+		t.Fatal(err)
+	}
+	body := `{"jsonrpc":"2.0","id":1,"result":{"status":"pong"}}`
+	if want := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body); out.String() != want {
+		t.Errorf("content-length: saída %q, want %q", out.String(), want)
+	}
+
+	for _, bad := range []string{"Content-Type: x\r\n\r\n{}", "Content-Length: abc\r\n\r\n{}", "Content-Length: 50\r\n\r\n{}"} {
+		err := ServeTransport(context.Background(), NewContentLengthTransport(strings.NewReader(bad), io.Discard), newTestServer(t, nil))
+		if err == nil {
+			t.Errorf("mensagem %q deveria encerrar com erro", bad)
+		}
+	}
+
+	// Uma linha maior que o buffer padrão de 64 KiB do Scanner.
+	big := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"ping","params":{"pad":%q}}`, strings.Repeat("x", 100*1024))
+	out.Reset()
+	if err := Serve(context.Background(), strings.NewReader(big+"\n"+ping+"\n"), &out, newTestServer(t, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), `"pong"`); got != 2 {
+		t.Errorf("newline: esperava 2 respostas, vieram %d:\n%s", got, out.String())
+	}
+}
+
+func TestRefSentOnlyWhenPresent(t *testing.T) {
+	var queries []string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if strings.HasSuffix(r.URL.Path, "/commits") {
+			w.Write([]byte(`[]`))
+			return
+		}
+		json.NewEncoder(w).Encode(GitHubContent{Name: "a.txt", Path: "a.txt", Type: "file", Content: "oi", Encoding: ""})
+	})
+
+	calls := []struct {
+		tool  string
+		args  map[string]interface{}
+		param string
+	}{
+		{"get_commits", map[string]interface{}{"repo": "o/r"}, "sha"},
+		{"get_content", map[string]interface{}{"repo": "o/r", "path": "a.txt"}, "ref"},
+		{"get_files", map[string]interface{}{"repo": "o/r", "paths": []interface{}{"a.txt"}}, "ref"},
+	}
+	for _, c := range calls {
+		for _, ref := range []interface{}{nil, "", "  ", "v1.0"} {
+			args := make(map[string]interface{})
+			for k, v := range c.args {
+				args[k] = v
+			}
+			if ref != nil {
+				args["ref"] = ref
+			}
+			queries = nil
+			resultText(t, callTool(t, server, c.tool, args))
+			if len(queries) != 1 {
+				t.Fatalf("%s: esperava 1 requisição, vieram %d", c.tool, len(queries))
+			}
+			query, _ := url.ParseQuery(queries[0])
+			_, sent := query[c.param]
+			if want := ref == "v1.0"; sent != want || (want && query.Get(c.param) != "v1.0") {
+				t.Errorf("%s com ref %#v: query %q", c.tool, ref, queries[0])
+			}
+		}
+	}
+}
+
+func TestGetPullRequestFiles(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/pulls/7/files" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"filename":"README.md","status":"modified","additions":1,"deletions":1,"changes":2,"patch":"@@ -1 +1 @@"}]`))
+			return
+		}
+		w.Header().Set("Link", `<http://`+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+		w.Write([]byte(`[{"filename":"main.go","status":"added","additions":10,"deletions":0,"changes":10,"patch":"+package main"}]`))
+	})
+
+	files, err := server.github.GetPullRequestFiles(context.Background(), "o", "r", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GitHubFile{
+		{Filename: "main.go", Status: "added", Additions: 10, Changes: 10, Patch: "+package main"},
+		{Filename: "README.md", Status: "modified", Additions: 1, Deletions: 1, Changes: 2, Patch: "@@ -1 +1 @@"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("esperava %d arquivos das duas páginas, vieram %+v", len(want), files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("arquivo %d = %+v, want %+v", i, files[i], want[i])
+		}
+	}
+
+	text := resultText(t, callTool(t, server, "get_pull_request_files", map[string]interface{}{"owner": "o", "repo": "r", "number": 7}))
+	if !strings.Contains(text, "Arquivos do PR #7 de o/r (2)") || !strings.Contains(text, "+10 -0") {
+		t.Errorf("resposta inesperada:\n%s", text)
+	}
+	if msg := callTool(t, server, "get_pull_request_files", map[string]interface{}{"owner": "o", "repo": "r", "pr_number": 7}); msg.Error == nil {
+		t.Error("get_pull_request_files sem number deveria ser recusado")
+	}
+}
+
+func TestListDirectory(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/contents/src") {
+			w.Write([]byte(`[{"name":"main.go","path":"src/main.go","type":"file","size":120},{"name":"pkg","path":"src/pkg","type":"dir","size":0}]`))
+			return
+		}
+		w.Write([]byte(`{"name":"README.md","path":"README.md","type":"file","size":42,"content":"b2k=","encoding":"base64"}`))
+	})
+
+	text := resultText(t, callTool(t, server, "list_directory", map[string]interface{}{"repo": "o/r", "path": "src"}))
+	for _, want := range []string{"main.go", "pkg", "dir", "120"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("listagem sem %q:\n%s", want, text)
+		}
+	}
+
+	msg := callTool(t, server, "list_directory", map[string]interface{}{"repo": "o/r", "path": "README.md"})
+	if msg.Error == nil || !strings.Contains(msg.Error.Data, "não é um diretório (tipo file)") {
+		t.Errorf("arquivo em list_directory deveria dar erro claro, veio %+v", msg.Error)
+	}
+
+	msg = callTool(t, server, "get_content", map[string]interface{}{"repo": "o/r", "path": "src"})
+	if msg.Error == nil || !strings.Contains(msg.Error.Data, "use list_directory") {
+		t.Errorf("diretório em get_content deveria apontar list_directory, veio %+v", msg.Error)
+	}
+}
+
+func TestGitHubErrorCodes(t *testing.T) {
+	cases := []struct {
+		status  int
+		code    int
+		message string
+	}{
+		{http.StatusNotFound, -32004, "Not found"},
+		{http.StatusUnauthorized, -32001, "Authentication error"},
+		{http.StatusForbidden, -32001, "Authentication error"},
+		{http.StatusUnprocessableEntity, -32603, "Internal error"},
+		{http.StatusInternalServerError, -32603, "Internal error"},
+	}
+	for _, c := range cases {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(`{"message":"falhou"}`))
+		})
+		calls := []struct {
+			tool string
+			args map[string]interface{}
+		}{
+			{"get_user", map[string]interface{}{"username": "octocat"}},
+			{"changelog", map[string]interface{}{"repo": "o/r", "from": "v1.0.0", "to": "v2.0.0"}},
+			{"repo_work_items", map[string]interface{}{"repo": "o/r"}},
+		}
+		for _, call := range calls {
+			msg := callTool(t, server, call.tool, call.args)
+			if msg.Error == nil || msg.Error.Code != c.code || msg.Error.Message != c.message {
+				t.Errorf("%s, status %d: erro %+v, want %d %s", call.tool, c.status, msg.Error, c.code, c.message)
+			}
+		}
+	}
+}
+
+func TestResolveTokenPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GH_CONFIG_DIR", dir)
+	hosts := "github.com:\n    user: ana\n    oauth_token: gho_do_gh\n    git_protocol: https\nghe.empresa.com:\n    oauth_token: gho_enterprise\n"
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(hosts), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("ghp_do_arquivo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		env, file, baseURL string
+		token, source      string
+	}{
+		{"ghp_do_env", tokenFile, "", "ghp_do_env", "GITHUB_TOKEN"},
+		{"", tokenFile, "", "ghp_do_arquivo", tokenFile},
+		{"", "", "", "gho_do_gh", filepath.Join(dir, "hosts.yml")},
+		{"", "", "https://ghe.empresa.com/api/v3", "gho_enterprise", filepath.Join(dir, "hosts.yml")},
+	}
+	for _, c := range cases {
+		t.Setenv("GITHUB_TOKEN", c.env)
+		token, source, err := resolveToken(c.file, c.baseURL)
+		if err != nil || token != c.token || source != c.source {
+			t.Errorf("resolveToken(%q, %q) com GITHUB_TOKEN=%q = %q, %q, %v; want %q, %q", c.file, c.baseURL, c.env, token, source, err, c.token, c.source)
+		}
+	}
+
+	t.Setenv("GITHUB_TOKEN", "")
+	if _, _, err := resolveToken(filepath.Join(dir, "inexistente"), ""); err == nil {
+		t.Error("--token-file inexistente deveria dar erro em vez de cair no gh")
+	}
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	if _, _, err := resolveToken("", ""); !errors.Is(err, errNoToken) {
+		t.Errorf("sem nenhuma fonte de token deveria dar errNoToken, veio %v", err)
+	}
+}
+
+func TestCreateIssue(t *testing.T) {
+	var method, path string
+	var payload struct {
+		Title  string   `json:"title"`
+		Body   string   `json:"body"`
+		Labels []string `json:"labels"`
+	}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number":42,"title":"Falha no login","html_url":"https://github.com/o/r/issues/42"}`))
+	})
+
+	text := resultText(t, callTool(t, server, "create_issue", map[string]interface{}{
+		"repo":   "o/r",
+		"title":  "  Falha no login ",
+		"body":   "Passos para reproduzir",
+		"labels": []interface{}{"bug", "auth"},
+	}))
+	if method != http.MethodPost || path != "/repos/o/r/issues" {
+		t.Errorf("requisição %s %s, want POST /repos/o/r/issues", method, path)
+	}
+	if payload.Title != "Falha no login" || payload.Body != "Passos para reproduzir" || strings.Join(payload.Labels, ",") != "bug,auth" {
+		t.Errorf("corpo enviado = %+v", payload)
+	}
+	if !strings.Contains(text, "Issue #42 criada em o/r") || !strings.Contains(text, "https://github.com/o/r/issues/42") {
+		t.Errorf("resposta inesperada:\n%s", text)
+	}
+}
+
+func TestCreateIssueComment(t *testing.T) {
+	var method, path, body string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if len(payload) != 1 {
+			t.Errorf("o corpo deveria ter só body: %v", payload)
+		}
+		body, _ = payload["body"].(string)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":99,"body":"LGTM","html_url":"https://github.com/o/r/issues/5#issuecomment-99","user":{"login":"ana"}}`))
+	})
+
+	text := resultText(t, callTool(t, server, "create_issue_comment", map[string]interface{}{"repo": "o/r", "number": 5, "body": "LGTM"}))
+	if method != http.MethodPost || path != "/repos/o/r/issues/5/comments" || body != "LGTM" {
+		t.Errorf("requisição %s %s com body %q", method, path, body)
+	}
+	if !strings.Contains(text, "Comentário 99 criado em #5 de o/r") || !strings.Contains(text, "issuecomment-99") {
+		t.Errorf("resposta inesperada:\n%s", text)
+	}
+}
+
+func TestGetIssueComments(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/issues/5/comments" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[
+			{"id":1,"body":"Consigo reproduzir.","created_at":"2024-03-01T10:00:00Z","html_url":"https://github.com/o/r/issues/5#issuecomment-1","user":{"login":"ana"}},
+			{"id":2,"body":"Corrigido em #6.","created_at":"2024-03-02T11:30:00Z","html_url":"https://github.com/o/r/issues/5#issuecomment-2","user":{"login":"bruno"}}
+		]`))
+	})
+
+	comments, err := server.github.GetIssueComments(context.Background(), "o", "r", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[1].User.Login != "bruno" || comments[1].CreatedAt != "2024-03-02T11:30:00Z" || comments[1].Body != "Corrigido em #6." {
+		t.Errorf("comentários decodificados = %+v", comments)
+	}
+
+	text := resultText(t, callTool(t, server, "get_issue_comments", map[string]interface{}{"repo": "o/r", "number": 5}))
+	for _, want := range []string{"Comentários de #5 em o/r (2)", "ana", "2024-03-01T10:00:00Z", "Consigo reproduzir.", "bruno", "Corrigido em #6."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("resposta sem %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCloseAndReopenIssue(t *testing.T) {
+	var method, path string
+	var payload map[string]string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		payload = nil
+		json.NewDecoder(r.Body).Decode(&payload)
+		fmt.Fprintf(w, `{"number":5,"title":"Bug","state":%q,"html_url":"https://github.com/o/r/issues/5"}`, payload["state"])
+	})
+
+	cases := []struct {
+		tool, reason string
+		want         map[string]string
+	}{
+		{"close_issue", "", map[string]string{"state": "closed"}},
+		{"close_issue", "not_planned", map[string]string{"state": "closed", "state_reason": "not_planned"}},
+		{"reopen_issue", "", map[string]string{"state": "open"}},
+		{"reopen_issue", "reopened", map[string]string{"state": "open", "state_reason": "reopened"}},
+	}
+	for _, c := range cases {
+		args := map[string]interface{}{"repo": "o/r", "number": 5}
+		if c.reason != "" {
+			args["state_reason"] = c.reason
+		}
+		text := resultText(t, callTool(t, server, c.tool, args))
+		if method != http.MethodPatch || path != "/repos/o/r/issues/5" {
+			t.Errorf("%s: requisição %s %s", c.tool, method, path)
+		}
+		if fmt.Sprint(payload) != fmt.Sprint(c.want) {
+			t.Errorf("%s com state_reason %q: corpo %v, want %v", c.tool, c.reason, payload, c.want)
+		}
+		if !strings.Contains(text, c.want["state"]) {
+			t.Errorf("%s: resposta sem o novo estado:\n%s", c.tool, text)
+		}
+	}
+
+	path = ""
+	if msg := callTool(t, server, "reopen_issue", map[string]interface{}{"repo": "o/r", "number": 5, "state_reason": "not_planned"}); msg.Error == nil || msg.Error.Code != -32602 {
+		t.Errorf("state_reason incompatível deveria dar -32602, veio %+v", msg.Error)
+	}
+	if path != "" {
+		t.Error("state_reason inválido não deveria chegar ao GitHub")
+	}
+}
+
+func TestGetIssuesStateAndLabels(t *testing.T) {
+	var query url.Values
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`[]`))
+	})
+
+	cases := []struct {
+		args          map[string]interface{}
+		state, labels string
+	}{
+		{map[string]interface{}{}, "open", ""},
+		{map[string]interface{}{"state": "closed"}, "closed", ""},
+		{map[string]interface{}{"state": "all"}, "all", ""},
+		{map[string]interface{}{"labels": " bug , ui,,"}, "open", "bug,ui"},
+		{map[string]interface{}{"state": "closed", "labels": "bug"}, "closed", "bug"},
+		{map[string]interface{}{"state": "all", "labels": "bug,ui"}, "all", "bug,ui"},
+	}
+	for _, c := range cases {
+		c.args["repo"] = "o/r"
+		query = nil
+		resultText(t, callTool(t, server, "get_issues", c.args))
+		if query.Get("state") != c.state || query.Get("labels") != c.labels {
+			t.Errorf("get_issues %v: query state=%q labels=%q, want %q e %q", c.args, query.Get("state"), query.Get("labels"), c.state, c.labels)
+		}
+		if _, sent := query["labels"]; sent != (c.labels != "") {
+			t.Errorf("get_issues %v: labels não deveria ser enviado vazio", c.args)
+		}
+	}
+
+	query = nil
+	if msg := callTool(t, server, "get_issues", map[string]interface{}{"repo": "o/r", "state": "merged"}); msg.Error == nil || msg.Error.Code != -32602 {
+		t.Errorf("state inválido deveria dar -32602, veio %+v", msg.Error)
+	}
+	if query != nil {
+		t.Error("state inválido não deveria chegar ao GitHub")
+	}
+}
+
+func TestGetIssuesExcludesPullRequests(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"number":1,"title":"Bug no login","state":"open"},
+			{"number":2,"title":"Corrige o login","state":"open","pull_request":{"url":"https://api.github.com/repos/o/r/pulls/2"}},
+			{"number":3,"title":"Documentação","state":"open"}
+		]`))
+	})
+
+	text := resultText(t, callTool(t, server, "get_issues", map[string]interface{}{"repo": "o/r"}))
+	if strings.Contains(text, "#2") || !strings.Contains(text, "#1") || !strings.Contains(text, "#3") {
+		t.Errorf("por padrão o PR #2 deveria ficar de fora:\n%s", text)
+	}
+
+	text = resultText(t, callTool(t, server, "get_issues", map[string]interface{}{"repo": "o/r", "include_prs": true}))
+	for _, want := range []string{"#1", "#2", "#3"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("com include_prs faltou %s:\n%s", want, text)
+		}
+	}
+}
+
+func TestSearchCode(t *testing.T) {
+	var rawQuery, accept string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		rawQuery, accept = r.URL.RawQuery, r.Header.Get("Accept")
+		w.Write([]byte(`{"total_count":7,"incomplete_results":false,"items":[
+			{"name":"mcp.go","path":"cmd/mcp.go","html_url":"https://github.com/o/r/blob/main/cmd/mcp.go","repository":{"full_name":"o/r"},"text_matches":[{"fragment":"func   NewMCPServer(\n token"}]}
+		]}`))
+	})
+
+	text := resultText(t, callTool(t, server, "search_code", map[string]interface{}{"query": "NewMCPServer language:go", "repo": "o/r"}))
+	query, _ := url.ParseQuery(rawQuery)
+	if query.Get("q") != "NewMCPServer language:go repo:o/r" {
+		t.Errorf("q = %q", query.Get("q"))
+	}
+	if !strings.Contains(rawQuery, "q=NewMCPServer+language%3Ago+repo%3Ao%2Fr") {
+		t.Errorf("q deveria ir codificado na URL: %s", rawQuery)
+	}
+	if !strings.Contains(accept, "text-match") {
+		t.Errorf("Accept deveria pedir text-match: %q", accept)
+	}
+	for _, want := range []string{"(1 de 7)", "cmd/mcp.go", "o/r", "func NewMCPServer( token"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("resposta sem %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestSearchRepositories(t *testing.T) {
+	var path string
+	var query url.Values
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		w.Write([]byte(`{"total_count":2,"incomplete_results":false,"items":[
+			{"name":"localmcp","full_name":"ana/localmcp","description":"Servidor MCP","language":"Go","stargazers_count":120,"updated_at":"2024-05-01T00:00:00Z","html_url":"https://github.com/ana/localmcp"},
+			{"name":"mcp-py","full_name":"bruno/mcp-py","description":"Cliente","language":"Python","stargazers_count":15,"updated_at":"2024-04-01T00:00:00Z","html_url":"https://github.com/bruno/mcp-py"}
+		]}`))
+	})
+
+	text := resultText(t, callTool(t, server, "search_repositories", map[string]interface{}{"query": "mcp stars:>10", "sort": "stars", "order": "desc"}))
+	if path != "/search/repositories" || query.Get("q") != "mcp stars:>10" || query.Get("sort") != "stars" || query.Get("order") != "desc" {
+		t.Errorf("requisição %s?%s", path, query.Encode())
+	}
+	for _, want := range []string{`Repositórios para "mcp stars:>10" (2)`, "ana/localmcp", "Servidor MCP", "Go", "120", "2024-05-01T00:00:00Z", "bruno/mcp-py"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("resposta sem %q:\n%s", want, text)
+		}
+	}
+
+	if msg := callTool(t, server, "search_repositories", map[string]interface{}{"query": "mcp", "sort": "nome"}); msg.Error == nil || msg.Error.Code != -32602 {
+		t.Errorf("sort inválido deveria dar -32602, veio %+v", msg.Error)
+	}
+}
+
+func TestGetBranches(t *testing.T) {
+	var path string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`[
+			{"name":"main","commit":{"sha":"a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0","url":"https://api.github.com/x"},"protected":true},
+			{"name":"feature/login","commit":{"sha":"0f9e8d7c6b5a49382716f5e4d3c2b1a098765432"},"protected":false}
+		]`))
+	})
+
+	msg := callTool(t, server, "get_branches", map[string]interface{}{"repo": "o/r"})
+	text := resultText(t, msg)
+	if path != "/repos/o/r/branches" {
+		t.Errorf("path = %s", path)
+	}
+	for _, want := range []string{"Branches de o/r (2)", "main", "sim", shortSHA("a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0"), "feature/login", shortSHA("0f9e8d7c6b5a49382716f5e4d3c2b1a098765432")} {
+		if !strings.Contains(text, want) {
+			t.Errorf("resposta sem %q:\n%s", want, text)
+		}
+	}
+
+	structured := msg.Result.(CallToolResult).StructuredContent.(map[string]interface{})
+	branches := structured["items"].([]GitHubBranch)
+	if len(branches) != 2 {
+		t.Fatalf("esperava 2 branches, vieram %d", len(branches))
+	}
+	if b := branches[0]; b.Name != "main" || b.Commit.SHA != "a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0" || !b.Protected {
+		t.Errorf("main decodificada como %+v", b)
+	}
+	if b := branches[1]; b.Name != "feature/login" || b.Commit.SHA != "0f9e8d7c6b5a49382716f5e4d3c2b1a098765432" || b.Protected {
+		t.Errorf("feature/login decodificada como %+v", b)
+	}
+}
+
+func TestGetReleases(t *testing.T) {
+	var paths []string
+	latestFound := true
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/o/r/releases":
+			w.Write([]byte(`[
+				{"tag_name":"v2.0.0-rc1","name":"RC","prerelease":true,"published_at":"2024-06-01T00:00:00Z","html_url":"https://github.com/o/r/releases/tag/v2.0.0-rc1"},
+				{"tag_name":"v1.0.0","name":"Primeira","body":"Notas da versão","published_at":"2024-01-01T00:00:00Z","html_url":"https://github.com/o/r/releases/tag/v1.0.0"}
+			]`))
+		case "/repos/o/r/releases/latest":
+			if !latestFound {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(`{"tag_name":"v1.0.0","name":"Primeira","published_at":"2024-01-01T00:00:00Z","html_url":"https://github.com/o/r/releases/tag/v1.0.0"}`))
+		default:
+			t.Errorf("requisição inesperada: %s", r.URL.Path)
+		}
+	})
+
+	text := resultText(t, callTool(t, server, "get_releases", map[string]interface{}{"repo": "o/r"}))
+	for _, want := range []string{"Releases de o/r (2)", "v2.0.0-rc1", "pre-release", "v1.0.0", "Primeira", "Notas da versão", "2024-01-01T00:00:00Z"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("listagem sem %q:\n%s", want, text)
+		}
+	}
+
+	msg := callTool(t, server, "get_releases", map[string]interface{}{"repo": "o/r", "latest": true})
+	text = resultText(t, msg)
+	if last := paths[len(paths)-1]; last != "/repos/o/r/releases/latest" {
+		t.Errorf("latest deveria chamar /releases/latest, chamou %s", last)
+	}
+	if !strings.Contains(text, "Última release de o/r") || !strings.Contains(text, "v1.0.0") || strings.Contains(text, "v2.0.0-rc1") {
+		t.Errorf("resposta inesperada para latest:\n%s", text)
+	}
+	structured := msg.Result.(CallToolResult).StructuredContent.(map[string]interface{})
+	if releases := structured["items"].([]GitHubRelease); len(releases) != 1 || releases[0].TagName != "v1.0.0" {
+		t.Errorf("structuredContent = %+v", releases)
+	}
+
+	latestFound = false
+	text = resultText(t, callTool(t, server, "get_releases", map[string]interface{}{"repo": "o/r", "latest": true}))
+	if !strings.Contains(text, "nenhuma release publicada") {
+		t.Errorf("sem release publicada, resposta:\n%s", text)
+	}
+}